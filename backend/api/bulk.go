@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Outcomes of a bulk import row.
+const (
+	BulkCreated   = "created"
+	BulkDuplicate = "duplicate"
+	BulkInvalid   = "invalid"
+	BulkFailed    = "failed"
+)
+
+const maxBulkParallelism = 64
+
+// bulkRow is one compliance report of a bulk import. The keys are the same
+// as for POST /create_asset: the company is looked up from the aircraft like
+// for a single report, and due_date or validity set the next due date.
+type bulkRow struct {
+	ID          string `json:"id"`
+	AircraftID  string `json:"aircraft_id"`
+	ReportDate  string `json:"report_date"`
+	Inspector   string `json:"inspector"`
+	Description string `json:"description"`
+	Compliance  string `json:"compliance"`
+	DueDate     string `json:"due_date"`
+	Validity    string `json:"validity"`
+	CheckType   string `json:"check_type"`
+
+	line     int
+	parseErr error
+}
+
+// BulkRowResult is the outcome of one row.
+type BulkRowResult struct {
+	Line   int    `json:"line"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// BulkReport summarizes a bulk import, with one result per row in input order.
+type BulkReport struct {
+	Total     int             `json:"total"`
+	Created   int             `json:"created"`
+	Duplicate int             `json:"duplicate"`
+	Invalid   int             `json:"invalid"`
+	Failed    int             `json:"failed"`
+	Rows      []BulkRowResult `json:"rows"`
+}
+
+// parseBulkRows reads CSV with a header line, or JSON lines, into rows. A
+// row that cannot be decoded is kept with its error so it is reported as
+// invalid instead of failing the whole import.
+func parseBulkRows(r io.Reader, format string) ([]bulkRow, error) {
+	switch format {
+	case "csv":
+		return parseBulkCSV(r)
+	case "jsonl", "ndjson", "json":
+		return parseBulkJSONLines(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected csv or jsonl", format)
+	}
+}
+
+func parseBulkCSV(r io.Reader) ([]bulkRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, fmt.Errorf("CSV header has no id column")
+	}
+
+	var rows []bulkRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read CSV: %v", err)
+			}
+			rows = append(rows, bulkRow{line: parseErr.Line, parseErr: err})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			rows = append(rows, bulkRow{line: line, parseErr: fmt.Errorf("expected %d fields, got %d", len(header), len(record))})
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		rows = append(rows, bulkRow{
+			ID:          field("id"),
+			AircraftID:  field("aircraft_id"),
+			ReportDate:  field("report_date"),
+			Inspector:   field("inspector"),
+			Description: field("description"),
+			Compliance:  field("compliance"),
+			DueDate:     field("due_date"),
+			Validity:    field("validity"),
+			CheckType:   field("check_type"),
+			line:        line,
+		})
+	}
+}
+
+func parseBulkJSONLines(r io.Reader) ([]bulkRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []bulkRow
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		// compliance may be given as a JSON boolean or as a string
+		var raw struct {
+			bulkRow
+			Compliance interface{} `json:"compliance"`
+		}
+		if err := json.Unmarshal(text, &raw); err != nil {
+			rows = append(rows, bulkRow{line: line, parseErr: fmt.Errorf("invalid JSON: %v", err)})
+			continue
+		}
+		row := raw.bulkRow
+		if raw.Compliance != nil {
+			row.Compliance = fmt.Sprint(raw.Compliance)
+		}
+		row.line = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON lines: %v", err)
+	}
+	return rows, nil
+}
+
+// validate checks a row before anything is sent to the ledger.
+func (r bulkRow) validate() error {
+	switch {
+	case r.parseErr != nil:
+		return r.parseErr
+	case r.ID == "":
+		return errors.New("id is required")
+	case r.AircraftID == "":
+		return errors.New("aircraft_id is required")
+	case strings.TrimSpace(r.CheckType) == "":
+		return errors.New("check_type is required")
+	}
+	if _, err := time.Parse("2006-01-02", r.ReportDate); err != nil {
+		return fmt.Errorf("report_date must be YYYY-MM-DD, got %q", r.ReportDate)
+	}
+	if _, err := strconv.ParseBool(r.Compliance); err != nil {
+		return fmt.Errorf("compliance must be true or false, got %q", r.Compliance)
+	}
+	if _, err := resolveDueDate(r.ReportDate, r.DueDate, r.Validity); err != nil {
+		return err
+	}
+	return nil
+}
+
+// bulkImporter submits rows with at most parallelism transactions in flight.
+type bulkImporter struct {
+	parallelism   int
+	lookupCompany func(ctx context.Context, assetID, aircraftID string) (*companyLookup, error)
+	airlineICAO   func(ctx context.Context, company string) (string, error)
+	submit        func(ctx context.Context, fn string, args ...string) error
+}
+
+func newBulkImporter(contract *client.Contract, parallelism int) *bulkImporter {
+	return &bulkImporter{
+		parallelism:   parallelism,
+		lookupCompany: lookupCompany,
+		airlineICAO: func(ctx context.Context, company string) (string, error) {
+			return airlineICAO(ctx, contract, company)
+		},
+		submit: func(ctx context.Context, fn string, args ...string) error {
+			_, err := contract.SubmitWithContext(ctx, fn, client.WithArguments(args...))
+			return err
+		},
+	}
+}
+
+func (b *bulkImporter) Import(ctx context.Context, rows []bulkRow) BulkReport {
+	results := make([]BulkRowResult, len(rows))
+	seen := make(map[string]int, len(rows))
+
+	parallelism := b.parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for i, row := range rows {
+		results[i] = BulkRowResult{Line: row.line, ID: row.ID}
+		if err := row.validate(); err != nil {
+			results[i].Status, results[i].Reason = BulkInvalid, err.Error()
+			continue
+		}
+		if first, ok := seen[row.ID]; ok {
+			results[i].Status = BulkDuplicate
+			results[i].Reason = fmt.Sprintf("id already used on line %d", first)
+			continue
+		}
+		seen[row.ID] = row.line
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(result *BulkRowResult, row bulkRow) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result.Status, result.Reason = b.importRow(ctx, row)
+		}(&results[i], row)
+	}
+	wg.Wait()
+
+	report := BulkReport{Total: len(rows), Rows: results}
+	for _, result := range results {
+		switch result.Status {
+		case BulkCreated:
+			report.Created++
+		case BulkDuplicate:
+			report.Duplicate++
+		case BulkInvalid:
+			report.Invalid++
+		default:
+			report.Failed++
+		}
+	}
+	return report
+}
+
+func (b *bulkImporter) importRow(ctx context.Context, row bulkRow) (string, string) {
+	if ctx.Err() != nil {
+		return BulkFailed, "import cancelled"
+	}
+
+	company, err := b.lookupCompany(ctx, row.ID, row.AircraftID)
+	if err != nil {
+		return BulkFailed, fmt.Sprintf("failed to look up the aircraft operator: %v", err)
+	}
+
+	icao, err := b.airlineICAO(ctx, company.Name)
+	if errors.Is(err, errUnknownAirline) {
+		return BulkInvalid, err.Error()
+	}
+	if err != nil {
+		_, apiErr := translateError("Failed to resolve airline", err)
+		return BulkFailed, apiErr.Error
+	}
+
+	compliance, _ := strconv.ParseBool(row.Compliance)
+	dueDate, _ := resolveDueDate(row.ReportDate, row.DueDate, row.Validity)
+	fn, args, err := createAssetTransaction([]string{
+		row.ID, icao, row.AircraftID, row.ReportDate, row.Inspector, row.Description, strconv.FormatBool(compliance),
+	}, company.Attestation, company.Review, dueDate, row.CheckType)
+	if err != nil {
+		return BulkFailed, err.Error()
+	}
+	err = b.submit(ctx, fn, args...)
+	if err != nil {
+		_, apiErr := translateError("Failed to create asset", err)
+		if apiErr.Code == ErrCodeAlreadyExists {
+			return BulkDuplicate, "asset already exists on the ledger"
+		}
+		return BulkFailed, apiErr.Error
+	}
+	return BulkCreated, company.Review
+}
+
+// bulkFormat picks the input format from ?format= or the Content-Type.
+func bulkFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return strings.ToLower(format)
+	}
+	contentType := c.ContentType()
+	switch {
+	case strings.Contains(contentType, "csv"):
+		return "csv"
+	case strings.Contains(contentType, "json"):
+		return "jsonl"
+	}
+	return ""
+}
+
+func bulkCreateAssets(c *gin.Context) {
+	rows, err := parseBulkRows(c.Request.Body, bulkFormat(c))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, err.Error())
+		return
+	}
+
+	parallelism := appConfig.BulkParallelism
+	if value := c.Query("parallelism"); value != "" {
+		parallelism, err = strconv.Atoi(value)
+		if err != nil || parallelism < 1 || parallelism > maxBulkParallelism {
+			respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, fmt.Sprintf("parallelism must be between 1 and %d", maxBulkParallelism))
+			return
+		}
+	}
+
+	report := newBulkImporter(getContract(), parallelism).Import(c.Request.Context(), rows)
+	c.JSON(http.StatusOK, report)
+}
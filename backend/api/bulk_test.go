@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aviation-compliance-dapp-oracle/oracle"
+
+	"google.golang.org/grpc/codes"
+)
+
+const testBulkCSV = `id,aircraft_id,report_date,inspector,description,compliance,check_type
+r1,PK-GFA,2024-12-01,Inspector Y,Engine check,true,A-check
+r2,PK-GFB,2024-12-01,,Gear check,false,A-check
+r3,PK-GFC,01/12/2024,Inspector Y,Bad date,true,A-check
+r1,PK-GFA,2024-12-02,Inspector Y,Same id,true,A-check
+r4,PK-GFD,2024-12-01,Inspector Y,On ledger,true,C-check
+r5,PK-GFE,2024-12-01,Inspector Y,Peer down,true,C-check
+r6,PK-GFF
+`
+
+func TestParseBulkRows(t *testing.T) {
+	rows, err := parseBulkRows(strings.NewReader(testBulkCSV), "csv")
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	if len(rows) != 7 || rows[0].Inspector != "Inspector Y" || rows[1].Compliance != "false" {
+		t.Fatalf("unexpected CSV rows: %+v", rows)
+	}
+	if rows[6].parseErr == nil || rows[6].line != 8 {
+		t.Errorf("expected short row on line 8 to be kept with an error, got %+v", rows[6])
+	}
+
+	jsonl := `{"id":"r1","aircraft_id":"PK-GFA","report_date":"2024-12-01","inspector":"Y","compliance":true,"check_type":"A-check"}
+
+{"id":"r2",`
+	rows, err = parseBulkRows(strings.NewReader(jsonl), "jsonl")
+	if err != nil {
+		t.Fatalf("failed to parse JSON lines: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Compliance != "true" || rows[0].validate() != nil {
+		t.Fatalf("unexpected JSON rows: %+v", rows)
+	}
+	if rows[1].parseErr == nil || rows[1].line != 3 {
+		t.Errorf("expected invalid JSON on line 3, got %+v", rows[1])
+	}
+
+	if _, err := parseBulkRows(strings.NewReader(""), "xlsx"); err == nil {
+		t.Errorf("expected unsupported format to fail")
+	}
+}
+
+func TestBulkImporterReport(t *testing.T) {
+	rows, err := parseBulkRows(strings.NewReader(testBulkCSV), "csv")
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+
+	var mu sync.Mutex
+	submitted := make(map[string][]string)
+	importer := &bulkImporter{
+		parallelism: 2,
+		lookupCompany: func(ctx context.Context, assetID, aircraftID string) (*companyLookup, error) {
+			return &companyLookup{Name: "Looked up " + aircraftID}, nil
+		},
+		airlineICAO: func(ctx context.Context, company string) (string, error) {
+			return "ICAO of " + company, nil
+		},
+		submit: func(ctx context.Context, fn string, args ...string) error {
+			switch args[0] {
+			case "r4":
+				return endorsementStatus(t, codes.Unknown, "chaincode response 500, Asset r4 already exists")
+			case "r5":
+				return errors.New("connection refused")
+			}
+			mu.Lock()
+			submitted[args[0]] = args
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	report := importer.Import(context.Background(), rows)
+	if report.Total != 7 || report.Created != 2 || report.Duplicate != 2 || report.Invalid != 2 || report.Failed != 1 {
+		t.Fatalf("unexpected summary: %+v", report)
+	}
+
+	expected := []string{BulkCreated, BulkCreated, BulkInvalid, BulkDuplicate, BulkDuplicate, BulkFailed, BulkInvalid}
+	for i, status := range expected {
+		if report.Rows[i].Status != status {
+			t.Errorf("row %d: expected %s, got %+v", i, status, report.Rows[i])
+		}
+	}
+	if got := submitted["r2"][1]; got != "ICAO of Looked up PK-GFB" {
+		t.Errorf("expected the airline of the looked up company for r2, got %q", got)
+	}
+	if got := submitted["r2"]; got[len(got)-1] != "A-check" {
+		t.Errorf("expected the check type as last argument for r2, got %q", got)
+	}
+	if report.Rows[5].Reason == "" {
+		t.Errorf("expected a reason for the failed row")
+	}
+}
+
+func TestBulkImporterBoundsParallelism(t *testing.T) {
+	var rows []bulkRow
+	for i := 0; i < 20; i++ {
+		rows = append(rows, bulkRow{
+			ID: string(rune('a' + i)), AircraftID: "PK-GFA",
+			ReportDate: "2024-12-01", Inspector: "Y", Compliance: "true", CheckType: "A-check", line: i + 2,
+		})
+	}
+
+	var inFlight, peak int32
+	importer := &bulkImporter{
+		parallelism: 3,
+		lookupCompany: func(ctx context.Context, assetID, aircraftID string) (*companyLookup, error) {
+			return &companyLookup{Name: "Garuda"}, nil
+		},
+		airlineICAO: func(ctx context.Context, company string) (string, error) {
+			return "GIA", nil
+		},
+		submit: func(ctx context.Context, fn string, args ...string) error {
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				seen := atomic.LoadInt32(&peak)
+				if current <= seen || atomic.CompareAndSwapInt32(&peak, seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			return nil
+		},
+	}
+
+	report := importer.Import(context.Background(), rows)
+	if report.Created != 20 {
+		t.Fatalf("expected every row to be created, got %+v", report)
+	}
+	if peak > 3 {
+		t.Errorf("expected at most 3 transactions in flight, saw %d", peak)
+	}
+}
+
+func TestBulkImporterLooksUpCompanyOffline(t *testing.T) {
+	registryFile, err := filepath.Abs("../oracle/registry/aircraft.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestConfig(t, testConfigYAML)
+	t.Setenv("ORACLE_PROVIDER", "registry")
+	t.Setenv("ORACLE_REGISTRY_FILE", registryFile)
+
+	previousConfig, previousProvider := appConfig, flightProvider
+	defer func() {
+		appConfig, flightProvider, aircraftRegistry = previousConfig, previousProvider, nil
+	}()
+	if appConfig, err = loadConfig(); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if err := setupOracle(); err != nil {
+		t.Fatalf("setupOracle failed: %v", err)
+	}
+
+	importer := newBulkImporter(nil, 1)
+	company, err := importer.lookupCompany(context.Background(), "r1", "PK-LKS")
+	if err != nil || company.Name != "Lion Air" || company.Attestation != nil {
+		t.Errorf("expected the operator of record, got %+v, %v", company, err)
+	}
+	if _, err := importer.lookupCompany(context.Background(), "r1", "GA404"); !errors.Is(err, oracle.ErrAircraftNotFound) {
+		t.Errorf("expected ErrAircraftNotFound for a flight number, got %v", err)
+	}
+}
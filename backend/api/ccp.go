@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PeerEndpoint is one gateway peer an organization can connect through.
+type PeerEndpoint struct {
+	Name         string
+	Address      string
+	TLSCACertPEM []byte
+	HostOverride string
+	Insecure     bool
+}
+
+// connectionProfile is the subset of a Fabric common connection profile
+// (as written by the test network's ccp-generate.sh) used by the API. The
+// YAML decoder reads the JSON flavour as well.
+type connectionProfile struct {
+	Name          string `yaml:"name"`
+	Organizations map[string]struct {
+		MSPID                  string   `yaml:"mspid"`
+		Peers                  []string `yaml:"peers"`
+		CertificateAuthorities []string `yaml:"certificateAuthorities"`
+	} `yaml:"organizations"`
+	Peers map[string]struct {
+		URL         string     `yaml:"url"`
+		TLSCACerts  tlsCACerts `yaml:"tlsCACerts"`
+		GRPCOptions struct {
+			SSLTargetNameOverride string `yaml:"ssl-target-name-override"`
+			HostnameOverride      string `yaml:"hostnameOverride"`
+		} `yaml:"grpcOptions"`
+	} `yaml:"peers"`
+	CertificateAuthorities map[string]struct {
+		URL        string     `yaml:"url"`
+		CAName     string     `yaml:"caName"`
+		TLSCACerts tlsCACerts `yaml:"tlsCACerts"`
+	} `yaml:"certificateAuthorities"`
+}
+
+type tlsCACerts struct {
+	PEM  pemList `yaml:"pem"`
+	Path string  `yaml:"path"`
+}
+
+// pemList accepts both a single PEM string and a list of PEM strings, since
+// profiles use either form.
+type pemList []string
+
+func (p *pemList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*p = pemList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*p = list
+	return nil
+}
+
+// load returns the concatenated PEM certificates, reading path relative to
+// the profile when no inline PEM is given.
+func (c tlsCACerts) load(baseDir string) ([]byte, error) {
+	if len(c.PEM) > 0 {
+		return []byte(strings.Join(c.PEM, "\n")), nil
+	}
+	if c.Path == "" {
+		return nil, nil
+	}
+	return os.ReadFile(resolvePath(baseDir, c.Path))
+}
+
+func loadConnectionProfile(path string) (*connectionProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connection profile: %w", err)
+	}
+
+	var profile connectionProfile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse connection profile %s: %w", path, err)
+	}
+	return &profile, nil
+}
+
+// applyConnectionProfile adds the organizations described by the profile at
+// path to the config. Settings given explicitly in the config win over the
+// profile.
+func (c *Config) applyConnectionProfile(path string) error {
+	profile, err := loadConnectionProfile(path)
+	if err != nil {
+		return err
+	}
+	baseDir := filepath.Dir(path)
+
+	if c.Organizations == nil {
+		c.Organizations = make(map[string]*OrgConfig)
+	}
+
+	orgNames := make([]string, 0, len(profile.Organizations))
+	for name := range profile.Organizations {
+		orgNames = append(orgNames, name)
+	}
+	sort.Strings(orgNames)
+
+	for _, name := range orgNames {
+		profileOrg := profile.Organizations[name]
+		if profileOrg.MSPID == "" {
+			return fmt.Errorf("organization %s in %s has no mspid", name, path)
+		}
+
+		org := c.Organizations[profileOrg.MSPID]
+		if org == nil {
+			org = &OrgConfig{}
+			c.Organizations[profileOrg.MSPID] = org
+		}
+
+		if len(org.Peers) == 0 && len(org.endpoints) == 0 {
+			for _, peerName := range profileOrg.Peers {
+				peer, ok := profile.Peers[peerName]
+				if !ok {
+					return fmt.Errorf("peer %s of %s is not defined in %s", peerName, name, path)
+				}
+
+				pemBytes, err := peer.TLSCACerts.load(baseDir)
+				if err != nil {
+					return fmt.Errorf("failed to load TLS CA certificate of peer %s: %w", peerName, err)
+				}
+
+				hostOverride := peer.GRPCOptions.SSLTargetNameOverride
+				if hostOverride == "" {
+					hostOverride = peer.GRPCOptions.HostnameOverride
+				}
+
+				address, insecure := parsePeerURL(peer.URL)
+				org.endpoints = append(org.endpoints, PeerEndpoint{
+					Name:         peerName,
+					Address:      address,
+					TLSCACertPEM: pemBytes,
+					HostOverride: hostOverride,
+					Insecure:     insecure,
+				})
+			}
+		}
+
+		if org.CA == nil && len(profileOrg.CertificateAuthorities) > 0 {
+			ca, ok := profile.CertificateAuthorities[profileOrg.CertificateAuthorities[0]]
+			if ok {
+				pemBytes, err := ca.TLSCACerts.load(baseDir)
+				if err != nil {
+					return fmt.Errorf("failed to load TLS CA certificate of CA %s: %w", ca.CAName, err)
+				}
+				org.CA = &CAConfig{URL: ca.URL, CAName: ca.CAName, TLSCertPEM: pemBytes}
+			}
+		}
+	}
+	return nil
+}
+
+// parsePeerURL strips the grpc:// or grpcs:// scheme from a profile URL.
+func parsePeerURL(url string) (address string, insecure bool) {
+	switch {
+	case strings.HasPrefix(url, "grpcs://"):
+		return strings.TrimPrefix(url, "grpcs://"), false
+	case strings.HasPrefix(url, "grpc://"):
+		return strings.TrimPrefix(url, "grpc://"), true
+	default:
+		return url, false
+	}
+}
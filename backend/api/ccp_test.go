@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const testProfileJSON = `{
+    "name": "test-network-org1",
+    "version": "1.0.0",
+    "client": {"organization": "Org1"},
+    "organizations": {
+        "Org1": {
+            "mspid": "Org1MSP",
+            "peers": ["peer0.org1.av.com", "peer1.org1.av.com"],
+            "certificateAuthorities": ["ca.org1.av.com"]
+        }
+    },
+    "peers": {
+        "peer0.org1.av.com": {
+            "url": "grpcs://localhost:7051",
+            "tlsCACerts": {"pem": "-----BEGIN CERTIFICATE-----\nPEER\n-----END CERTIFICATE-----\n"},
+            "grpcOptions": {"ssl-target-name-override": "peer0.org1.av.com", "hostnameOverride": "peer0.org1.av.com"}
+        },
+        "peer1.org1.av.com": {
+            "url": "grpcs://localhost:8051",
+            "tlsCACerts": {"path": "peer1-tls.pem"},
+            "grpcOptions": {"hostnameOverride": "peer1.org1.av.com"}
+        }
+    },
+    "certificateAuthorities": {
+        "ca.org1.av.com": {
+            "url": "https://localhost:7054",
+            "caName": "ca-org1",
+            "tlsCACerts": {"pem": ["-----BEGIN CERTIFICATE-----\nCA\n-----END CERTIFICATE-----\n"]},
+            "httpOptions": {"verify": false}
+        }
+    }
+}`
+
+func TestApplyConnectionProfile(t *testing.T) {
+	dir := t.TempDir()
+	profilePath := filepath.Join(dir, "connection-org1.json")
+	os.WriteFile(profilePath, []byte(testProfileJSON), 0o600)
+	os.WriteFile(filepath.Join(dir, "peer1-tls.pem"), []byte("PEER1 PEM"), 0o600)
+
+	configPath := filepath.Join(dir, "config.yaml")
+	os.WriteFile(configPath, []byte("channel: channel1\nchaincode: basic\nconnectionProfiles: [connection-org1.json]\n"), 0o600)
+	t.Setenv("API_CONFIG", configPath)
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+
+	org, err := config.Organization("Org1MSP")
+	if err != nil {
+		t.Fatalf("expected Org1MSP from the profile: %v", err)
+	}
+	endpoints, err := org.Endpoints()
+	if err != nil {
+		t.Fatalf("Endpoints failed: %v", err)
+	}
+	if len(endpoints) != 2 {
+		t.Fatalf("expected 2 endpoints, got %d", len(endpoints))
+	}
+	if endpoints[0].Address != "localhost:7051" || endpoints[0].HostOverride != "peer0.org1.av.com" {
+		t.Errorf("unexpected first endpoint: %+v", endpoints[0])
+	}
+	if string(endpoints[1].TLSCACertPEM) != "PEER1 PEM" || endpoints[1].HostOverride != "peer1.org1.av.com" {
+		t.Errorf("expected TLS CA to be read from path, got %+v", endpoints[1])
+	}
+
+	ca, ok := config.caConfig("Org1MSP")
+	if !ok || ca.CAName != "ca-org1" || len(ca.TLSCertPEM) == 0 {
+		t.Errorf("expected CA from the profile, got %+v", ca)
+	}
+}
+
+func TestUpdateGrpcConnectionFailsOver(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(listener)
+	defer server.Stop()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	unavailable := closed.Addr().String()
+	closed.Close()
+
+	previous := appConfig
+	appConfig = &Config{ConnectTimeout: 5 * time.Second}
+	defer func() { appConfig = previous }()
+
+	org := &OrgConfig{MSPID: "Org1MSP", endpoints: []PeerEndpoint{
+		{Name: "peer0", Address: unavailable, Insecure: true},
+		{Name: "peer1", Address: listener.Addr().String(), Insecure: true},
+	}}
+
+	connection, endpoint, err := updateGrpcConnection(org)
+	if err != nil {
+		t.Fatalf("expected failover to the second peer: %v", err)
+	}
+	defer connection.Close()
+	if connection.Target() != listener.Addr().String() {
+		t.Errorf("expected connection to %s, got %s", listener.Addr(), connection.Target())
+	}
+	if endpoint.Name != "peer1" {
+		t.Errorf("expected endpoint peer1, got %s", endpoint.Name)
+	}
+
+	org.endpoints = org.endpoints[:1]
+	if _, _, err := updateGrpcConnection(org); err == nil {
+		t.Errorf("expected an error when no peer is reachable")
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"aviation-compliance-dapp-wallet/wallet"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	certStatusOK       = "ok"
+	certStatusWarning  = "warning"
+	certStatusCritical = "critical"
+	certStatusExpired  = "expired"
+	certStatusInvalid  = "invalid"
+)
+
+// CertificateStatus describes the expiry state of one wallet identity.
+type CertificateStatus struct {
+	Label        string    `json:"label"`
+	MSP          string    `json:"msp"`
+	Subject      string    `json:"subject,omitempty"`
+	NotAfter     time.Time `json:"notAfter"`
+	DaysToExpiry int       `json:"daysToExpiry"`
+	Status       string    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	Reenrolled   bool      `json:"reenrolled,omitempty"`
+}
+
+// CertificateConfig drives the scan for wallet certificates nearing expiry.
+// Certificates expiring within WarnDays are reported as warning, within
+// CriticalDays as critical.
+type CertificateConfig struct {
+	Interval     time.Duration `yaml:"interval"`
+	WarnDays     int           `yaml:"warnDays"`
+	CriticalDays int           `yaml:"criticalDays"`
+}
+
+// CertMonitor periodically scans the wallet for certificates nearing expiry
+// and re-enrolls them when the owning MSP has CA credentials configured.
+type CertMonitor struct {
+	store        wallet.WalletStore
+	warnDays     int
+	criticalDays int
+	interval     time.Duration
+	caConfig     func(msp string) (*CAConfig, bool)
+	reenroll     func(identity wallet.X509Identity, ca *CAConfig) (wallet.X509Identity, error)
+	now          func() time.Time
+
+	mu       sync.RWMutex
+	statuses []CertificateStatus
+	lastScan time.Time
+}
+
+func NewCertMonitor(store wallet.WalletStore, config CertificateConfig) *CertMonitor {
+	return &CertMonitor{
+		store:        store,
+		warnDays:     config.WarnDays,
+		criticalDays: config.CriticalDays,
+		interval:     config.Interval,
+		caConfig:     appConfig.caConfig,
+		reenroll:     reenrollIdentity,
+		now:          time.Now,
+	}
+}
+
+func parseCertificate(certPEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+	return cert, nil
+}
+
+// Run scans immediately and then on every interval until stop is closed.
+func (m *CertMonitor) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.Scan()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Scan checks every identity in the wallet and returns their status.
+func (m *CertMonitor) Scan() []CertificateStatus {
+	labels, err := m.store.List()
+	if err != nil {
+		log.Printf("Certificate monitor failed to list wallet identities: %v", err)
+		return m.Statuses()
+	}
+	sort.Strings(labels)
+
+	statuses := make([]CertificateStatus, 0, len(labels))
+	for _, label := range labels {
+		statuses = append(statuses, m.check(label))
+	}
+
+	m.mu.Lock()
+	m.statuses = statuses
+	m.lastScan = m.now()
+	m.mu.Unlock()
+
+	return statuses
+}
+
+func (m *CertMonitor) check(label string) CertificateStatus {
+	status := CertificateStatus{Label: label}
+
+	identity, original, err := m.load(label)
+	if err != nil {
+		status.Status = certStatusInvalid
+		status.Error = err.Error()
+		log.Printf("Certificate monitor cannot read identity %s: %v", label, err)
+		return status
+	}
+	status.MSP = identity.MSP
+
+	cert, err := parseCertificate(identity.Cert)
+	if err != nil {
+		status.Status = certStatusInvalid
+		status.Error = err.Error()
+		log.Printf("Certificate monitor cannot parse certificate of %s: %v", label, err)
+		return status
+	}
+	m.describe(&status, cert)
+
+	if status.Status == certStatusOK {
+		return status
+	}
+	if status.Status == certStatusExpired {
+		log.Printf("WARNING: certificate of %s (%s) expired on %s",
+			label, identity.MSP, status.NotAfter.Format(time.RFC3339))
+		return status
+	}
+	log.Printf("WARNING: certificate of %s (%s) expires in %d days on %s",
+		label, identity.MSP, status.DaysToExpiry, status.NotAfter.Format(time.RFC3339))
+
+	if status.Status != certStatusCritical {
+		return status
+	}
+	ca, ok := m.caConfig(identity.MSP)
+	if !ok {
+		return status
+	}
+
+	renewed, err := m.reenroll(identity, ca)
+	if err != nil {
+		status.Error = fmt.Sprintf("re-enrollment failed: %v", err)
+		log.Printf("Failed to re-enroll %s: %v", label, err)
+		return status
+	}
+
+	// Do not overwrite an identity replaced while the CA request was running
+	data, err := renewed.ToJSON()
+	if err == nil {
+		if cas, ok := m.store.(wallet.CASWalletStore); ok {
+			err = cas.CompareAndPut(label, original, data)
+		} else {
+			err = m.store.Put(label, data)
+		}
+	}
+	if err != nil {
+		status.Error = fmt.Sprintf("failed to store re-enrolled identity: %v", err)
+		log.Printf("Failed to store re-enrolled identity %s: %v", label, err)
+		return status
+	}
+
+	renewedCert, err := parseCertificate(renewed.Cert)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	m.describe(&status, renewedCert)
+	status.Reenrolled = true
+	log.Printf("Re-enrolled %s, new certificate valid until %s", label, status.NotAfter.Format(time.RFC3339))
+	return status
+}
+
+func (m *CertMonitor) load(label string) (wallet.X509Identity, []byte, error) {
+	data, err := m.store.Get(label)
+	if err != nil {
+		return wallet.X509Identity{}, nil, err
+	}
+
+	identity, err := wallet.X509IdentityFromJSON(data)
+	if err != nil {
+		return wallet.X509Identity{}, nil, err
+	}
+	return *identity, data, nil
+}
+
+func (m *CertMonitor) describe(status *CertificateStatus, cert *x509.Certificate) {
+	remaining := cert.NotAfter.Sub(m.now())
+
+	status.Subject = cert.Subject.CommonName
+	status.NotAfter = cert.NotAfter
+	status.DaysToExpiry = int(math.Floor(remaining.Hours() / 24))
+
+	switch {
+	case remaining <= 0:
+		status.Status = certStatusExpired
+	case status.DaysToExpiry < m.criticalDays:
+		status.Status = certStatusCritical
+	case status.DaysToExpiry < m.warnDays:
+		status.Status = certStatusWarning
+	default:
+		status.Status = certStatusOK
+	}
+}
+
+// Statuses returns the result of the most recent scan.
+func (m *CertMonitor) Statuses() []CertificateStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]CertificateStatus(nil), m.statuses...)
+}
+
+func (m *CertMonitor) writeMetrics(w io.Writer) {
+	statuses := m.Statuses()
+
+	samples := make([]metricSample, 0, len(statuses))
+	for _, status := range statuses {
+		if status.Status == certStatusInvalid {
+			continue
+		}
+		samples = append(samples, metricSample{
+			Labels: map[string]string{"label": status.Label, "msp": status.MSP},
+			Value:  float64(status.DaysToExpiry),
+		})
+	}
+	writeMetric(w, "wallet_certificate_days_to_expiry", "gauge",
+		"Days until the wallet identity certificate expires.", samples)
+}
+
+// getCertificates returns the result of the most recent scan.
+func (m *CertMonitor) getCertificates(c *gin.Context) {
+	m.respondCertificates(c, m.Statuses())
+}
+
+// scanCertificates scans now rather than on the next interval. Like a
+// scheduled scan it may re-enroll identities and rewrite the wallet.
+func (m *CertMonitor) scanCertificates(c *gin.Context) {
+	m.respondCertificates(c, m.Scan())
+}
+
+func (m *CertMonitor) respondCertificates(c *gin.Context, statuses []CertificateStatus) {
+	m.mu.RLock()
+	lastScan := m.lastScan
+	m.mu.RUnlock()
+
+	c.JSON(http.StatusOK, gin.H{"lastScan": lastScan, "certificates": statuses})
+}
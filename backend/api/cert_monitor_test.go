@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"aviation-compliance-dapp-wallet/wallet"
+)
+
+var testNow = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestIdentity(t *testing.T, msp string, notAfter time.Time) wallet.X509Identity {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "User1"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	return wallet.X509Identity{
+		MSP:  msp,
+		Cert: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})),
+		Key:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+func newTestMonitor(store wallet.WalletStore) *CertMonitor {
+	monitor := NewCertMonitor(store, CertificateConfig{Interval: time.Hour, WarnDays: 30, CriticalDays: 7})
+	monitor.now = func() time.Time { return testNow }
+	monitor.caConfig = func(msp string) (*CAConfig, bool) { return nil, false }
+	return monitor
+}
+
+func putIdentity(t *testing.T, store wallet.WalletStore, label string, identity wallet.X509Identity) {
+	t.Helper()
+	data, err := identity.ToJSON()
+	if err != nil {
+		t.Fatalf("failed to encode identity: %v", err)
+	}
+	if err := store.Put(label, data); err != nil {
+		t.Fatalf("failed to store identity: %v", err)
+	}
+}
+
+func TestCertMonitorThresholds(t *testing.T) {
+	store := &wallet.FileWalletStore{}
+	putIdentity(t, store, "ok", newTestIdentity(t, "Org1MSP", testNow.AddDate(0, 0, 90)))
+	putIdentity(t, store, "warning", newTestIdentity(t, "Org1MSP", testNow.AddDate(0, 0, 20)))
+	putIdentity(t, store, "critical", newTestIdentity(t, "Org1MSP", testNow.AddDate(0, 0, 3)))
+	putIdentity(t, store, "expired", newTestIdentity(t, "Org1MSP", testNow.AddDate(0, 0, -1)))
+	store.Put("broken", []byte("not json"))
+
+	statuses := newTestMonitor(store).Scan()
+
+	expected := map[string]string{
+		"ok":       certStatusOK,
+		"warning":  certStatusWarning,
+		"critical": certStatusCritical,
+		"expired":  certStatusExpired,
+		"broken":   certStatusInvalid,
+	}
+	if len(statuses) != len(expected) {
+		t.Fatalf("expected %d statuses, got %d", len(expected), len(statuses))
+	}
+	for _, status := range statuses {
+		if status.Status != expected[status.Label] {
+			t.Errorf("%s: expected status %s, got %s", status.Label, expected[status.Label], status.Status)
+		}
+	}
+	if statuses[3].Label != "ok" || statuses[3].DaysToExpiry != 90 {
+		t.Errorf("expected ok identity to expire in 90 days, got %+v", statuses[3])
+	}
+}
+
+func TestCertMonitorReenrollsAndSwapsSession(t *testing.T) {
+	store := &wallet.FileWalletStore{}
+	original := newTestIdentity(t, "Org1MSP", testNow.AddDate(0, 0, 2))
+	renewed := newTestIdentity(t, "Org1MSP", testNow.AddDate(1, 0, 0))
+	putIdentity(t, store, "user_identity", original)
+
+	live, err := newGatewaySession("user_identity", original)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	monitor := newTestMonitor(store)
+	monitor.caConfig = func(msp string) (*CAConfig, bool) {
+		return &CAConfig{URL: "https://ca.org1.av.com:7054"}, msp == "Org1MSP"
+	}
+	monitor.reenroll = func(identity wallet.X509Identity, ca *CAConfig) (wallet.X509Identity, error) {
+		if identity.Cert != original.Cert {
+			t.Errorf("expected the expiring identity to be re-enrolled")
+		}
+		return renewed, nil
+	}
+	setCurrentSession(live)
+	defer setCurrentSession(nil)
+	events, cancel := store.Watch()
+	defer cancel()
+
+	statuses := monitor.Scan()
+	if len(statuses) != 1 || !statuses[0].Reenrolled || statuses[0].Status != certStatusOK {
+		t.Fatalf("expected identity to be re-enrolled, got %+v", statuses)
+	}
+
+	stored, _, err := monitor.load("user_identity")
+	if err != nil {
+		t.Fatalf("failed to load stored identity: %v", err)
+	}
+	if stored.Cert != renewed.Cert {
+		t.Errorf("expected re-enrolled certificate to be stored in the wallet")
+	}
+
+	event := <-events
+	if event.Type != wallet.IdentityRotated {
+		t.Fatalf("expected a rotation event, got %+v", event)
+	}
+	handleWalletEvent(store, event)
+	if string(live.Credentials()) != renewed.Cert {
+		t.Errorf("expected live session to use the re-enrolled certificate")
+	}
+}
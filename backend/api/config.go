@@ -0,0 +1,405 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"aviation-compliance-dapp-oracle/oracle"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigPath = "config.yaml"
+
+// Config describes the Fabric network the API talks to. It is read from
+// the YAML file named by API_CONFIG (default config.yaml) and then
+// overridden by environment variables, see applyEnv.
+type Config struct {
+	Channel             string                `yaml:"channel"`
+	Chaincode           string                `yaml:"chaincode"`
+	ConnectTimeout      time.Duration         `yaml:"connectTimeout"`
+	KeepaliveTime       time.Duration         `yaml:"keepaliveTime"`
+	KeepaliveTimeout    time.Duration         `yaml:"keepaliveTimeout"`
+	ReconnectBackoff    time.Duration         `yaml:"reconnectBackoff"`
+	ReconnectMaxBackoff time.Duration         `yaml:"reconnectMaxBackoff"`
+	Timeouts            TimeoutConfig         `yaml:"timeouts"`
+	BulkParallelism     int                   `yaml:"bulkParallelism"`
+	Oracle              oracle.Config         `yaml:"oracle"`
+	Expiry              ExpiryConfig          `yaml:"expiry"`
+	Certificates        CertificateConfig     `yaml:"certificates"`
+	Transfers           TransferConfig        `yaml:"transfers"`
+	ConnectionProfiles  []string              `yaml:"connectionProfiles"`
+	Organizations       map[string]*OrgConfig `yaml:"organizations"`
+}
+
+// TimeoutConfig bounds each step of a gateway call. A request is also
+// cancelled when its HTTP client goes away.
+type TimeoutConfig struct {
+	Evaluate     time.Duration `yaml:"evaluate"`
+	Endorse      time.Duration `yaml:"endorse"`
+	Submit       time.Duration `yaml:"submit"`
+	CommitStatus time.Duration `yaml:"commitStatus"`
+}
+
+// OrgConfig is the connection setup for one MSP ID.
+type OrgConfig struct {
+	MSPID        string    `yaml:"-"`
+	Peers        []string  `yaml:"peers"`
+	TLSCACert    string    `yaml:"tlsCACert"`
+	HostOverride string    `yaml:"hostOverride"`
+	Channel      string    `yaml:"channel"`
+	Chaincode    string    `yaml:"chaincode"`
+	CA           *CAConfig `yaml:"ca"`
+
+	// endpoints are taken from a connection profile when Peers is empty
+	endpoints []PeerEndpoint
+}
+
+// CAConfig holds the Fabric CA an MSP's identities can be re-enrolled against.
+type CAConfig struct {
+	URL         string `yaml:"url"`
+	CAName      string `yaml:"name"`
+	TLSCertPath string `yaml:"tlsCACert"`
+	TLSCertPEM  []byte `yaml:"-"`
+}
+
+var appConfig = &Config{}
+
+func loadConfig() (*Config, error) {
+	path := os.Getenv("API_CONFIG")
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath
+	}
+
+	config := &Config{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// Without a file the network is described by the environment only,
+		// FABRIC_ORGS names its organizations
+	default:
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	baseDir := filepath.Dir(path)
+	if profiles := os.Getenv("FABRIC_CONNECTION_PROFILES"); profiles != "" {
+		config.ConnectionProfiles = strings.Split(profiles, ",")
+	}
+	for _, profile := range config.ConnectionProfiles {
+		if err := config.applyConnectionProfile(resolvePath(baseDir, strings.TrimSpace(profile))); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := config.resolve(baseDir); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// applyEnv overrides the file with FABRIC_CHANNEL, FABRIC_CHAINCODE, the
+// FABRIC_TIMEOUT_EVALUATE, _ENDORSE, _SUBMIT and _COMMIT_STATUS durations
+// and FABRIC_ORGS, comma separated MSP IDs of organizations to add to those
+// in the file. For each organization it takes FABRIC_ORG_<MSPID>_PEERS
+// (comma separated), _TLS_CA_CERT, _HOST_OVERRIDE, _CHANNEL and _CHAINCODE,
+// plus FABRIC_CA_<MSPID>_URL, _NAME and _TLS_CERT for re-enrollment. The
+// expiry scheduler takes EXPIRY_SCAN_INTERVAL and EXPIRY_WEBHOOKS, aircraft
+// transfers TRANSFER_WEBHOOKS (comma separated) and the wallet certificate
+// scan CERT_EXPIRY_SCAN_INTERVAL, CERT_EXPIRY_WARN_DAYS and
+// CERT_EXPIRY_CRITICAL_DAYS.
+func (c *Config) applyEnv() error {
+	overrideString(&c.Channel, "FABRIC_CHANNEL")
+	overrideString(&c.Chaincode, "FABRIC_CHAINCODE")
+	if err := overrideDuration(&c.Expiry.Interval, "EXPIRY_SCAN_INTERVAL"); err != nil {
+		return err
+	}
+	overrideList(&c.Expiry.Webhooks, "EXPIRY_WEBHOOKS")
+	overrideList(&c.Transfers.Webhooks, "TRANSFER_WEBHOOKS")
+	if err := overrideDuration(&c.Certificates.Interval, "CERT_EXPIRY_SCAN_INTERVAL"); err != nil {
+		return err
+	}
+	if err := overrideInt(&c.Certificates.WarnDays, "CERT_EXPIRY_WARN_DAYS"); err != nil {
+		return err
+	}
+	if err := overrideInt(&c.Certificates.CriticalDays, "CERT_EXPIRY_CRITICAL_DAYS"); err != nil {
+		return err
+	}
+	if err := c.Oracle.ApplyEnv(); err != nil {
+		return err
+	}
+
+	timeouts := map[string]*time.Duration{
+		"FABRIC_TIMEOUT_EVALUATE":      &c.Timeouts.Evaluate,
+		"FABRIC_TIMEOUT_ENDORSE":       &c.Timeouts.Endorse,
+		"FABRIC_TIMEOUT_SUBMIT":        &c.Timeouts.Submit,
+		"FABRIC_TIMEOUT_COMMIT_STATUS": &c.Timeouts.CommitStatus,
+	}
+	for name, target := range timeouts {
+		if err := overrideDuration(target, name); err != nil {
+			return err
+		}
+	}
+
+	var orgs []string
+	overrideList(&orgs, "FABRIC_ORGS")
+	for _, mspID := range orgs {
+		if c.Organizations == nil {
+			c.Organizations = make(map[string]*OrgConfig)
+		}
+		if _, ok := c.Organizations[mspID]; !ok {
+			c.Organizations[mspID] = nil
+		}
+	}
+
+	for mspID, org := range c.Organizations {
+		if org == nil {
+			org = &OrgConfig{}
+			c.Organizations[mspID] = org
+		}
+
+		prefix := "FABRIC_ORG_" + strings.ToUpper(mspID) + "_"
+		if peers := os.Getenv(prefix + "PEERS"); peers != "" {
+			org.Peers = nil
+			for _, peer := range strings.Split(peers, ",") {
+				if peer = strings.TrimSpace(peer); peer != "" {
+					org.Peers = append(org.Peers, peer)
+				}
+			}
+		}
+		overrideString(&org.TLSCACert, prefix+"TLS_CA_CERT")
+		overrideString(&org.HostOverride, prefix+"HOST_OVERRIDE")
+		overrideString(&org.Channel, prefix+"CHANNEL")
+		overrideString(&org.Chaincode, prefix+"CHAINCODE")
+
+		caPrefix := "FABRIC_CA_" + strings.ToUpper(mspID) + "_"
+		if url := os.Getenv(caPrefix + "URL"); url != "" {
+			if org.CA == nil {
+				org.CA = &CAConfig{}
+			}
+			org.CA.URL = url
+		}
+		if org.CA != nil {
+			overrideString(&org.CA.CAName, caPrefix+"NAME")
+			overrideString(&org.CA.TLSCertPath, caPrefix+"TLS_CERT")
+		}
+	}
+	return nil
+}
+
+func overrideString(target *string, name string) {
+	if value := os.Getenv(name); value != "" {
+		*target = value
+	}
+}
+
+// overrideList replaces target with the comma separated values of name.
+func overrideList(target *[]string, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	*target = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*target = append(*target, item)
+		}
+	}
+}
+
+func overrideDuration(target *time.Duration, name string) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = duration
+	return nil
+}
+
+func overrideInt(target *int, name string) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = n
+	return nil
+}
+
+// resolve fills in per-organization defaults, makes file paths relative to
+// the config file and checks that every organization is usable.
+func (c *Config) resolve(baseDir string) error {
+	if len(c.Organizations) == 0 {
+		return fmt.Errorf("no organizations configured")
+	}
+	if c.ConnectTimeout <= 0 {
+		c.ConnectTimeout = 5 * time.Second
+	}
+	if c.KeepaliveTime <= 0 {
+		c.KeepaliveTime = time.Minute
+	}
+	if c.KeepaliveTimeout <= 0 {
+		c.KeepaliveTimeout = 20 * time.Second
+	}
+	if c.ReconnectBackoff <= 0 {
+		c.ReconnectBackoff = time.Second
+	}
+	if c.ReconnectMaxBackoff < c.ReconnectBackoff {
+		c.ReconnectMaxBackoff = 30 * time.Second
+	}
+	if c.BulkParallelism <= 0 {
+		c.BulkParallelism = 8
+	}
+	defaultDuration(&c.Timeouts.Evaluate, 5*time.Second)
+	defaultDuration(&c.Timeouts.Endorse, 15*time.Second)
+	defaultDuration(&c.Timeouts.Submit, 5*time.Second)
+	defaultDuration(&c.Timeouts.CommitStatus, time.Minute)
+	defaultDuration(&c.Expiry.Interval, time.Hour)
+	if c.Expiry.WarnDays <= 0 {
+		c.Expiry.WarnDays = 30
+	}
+	defaultDuration(&c.Certificates.Interval, time.Hour)
+	if c.Certificates.WarnDays <= 0 {
+		c.Certificates.WarnDays = 30
+	}
+	if c.Certificates.CriticalDays <= 0 {
+		c.Certificates.CriticalDays = 7
+	}
+	if c.Oracle.Static.File != "" {
+		c.Oracle.Static.File = resolvePath(baseDir, c.Oracle.Static.File)
+	}
+	if c.Oracle.Registry.File != "" {
+		c.Oracle.Registry.File = resolvePath(baseDir, c.Oracle.Registry.File)
+	}
+	if c.Oracle.Registry.Source != "" && !strings.Contains(c.Oracle.Registry.Source, "://") {
+		c.Oracle.Registry.Source = resolvePath(baseDir, c.Oracle.Registry.Source)
+	}
+	if c.Oracle.Fixtures.Dir != "" {
+		c.Oracle.Fixtures.Dir = resolvePath(baseDir, c.Oracle.Fixtures.Dir)
+	}
+	if c.Oracle.AviationStack.KeyFile != "" {
+		c.Oracle.AviationStack.KeyFile = resolvePath(baseDir, c.Oracle.AviationStack.KeyFile)
+	}
+	if c.Oracle.Signer.KeyFile != "" {
+		c.Oracle.Signer.KeyFile = resolvePath(baseDir, c.Oracle.Signer.KeyFile)
+	}
+	if c.Oracle.Cache.File != "" {
+		c.Oracle.Cache.File = resolvePath(baseDir, c.Oracle.Cache.File)
+	}
+
+	for mspID, org := range c.Organizations {
+		org.MSPID = mspID
+		if org.Channel == "" {
+			org.Channel = c.Channel
+		}
+		if org.Chaincode == "" {
+			org.Chaincode = c.Chaincode
+		}
+
+		switch {
+		case len(org.Peers) == 0 && len(org.endpoints) == 0:
+			return fmt.Errorf("organization %s has no peers", mspID)
+		case len(org.Peers) > 0 && org.TLSCACert == "":
+			return fmt.Errorf("organization %s has no TLS CA certificate", mspID)
+		case org.Channel == "" || org.Chaincode == "":
+			return fmt.Errorf("organization %s has no channel or chaincode", mspID)
+		}
+
+		if org.TLSCACert != "" {
+			org.TLSCACert = resolvePath(baseDir, org.TLSCACert)
+		}
+		if org.CA != nil {
+			org.CA.URL = strings.TrimRight(org.CA.URL, "/")
+			if org.CA.TLSCertPath != "" {
+				org.CA.TLSCertPath = resolvePath(baseDir, org.CA.TLSCertPath)
+			}
+		}
+	}
+	return nil
+}
+
+func defaultDuration(target *time.Duration, value time.Duration) {
+	if *target <= 0 {
+		*target = value
+	}
+}
+
+// ConnectOptions applies the configured call timeouts to a gateway.
+func (t TimeoutConfig) ConnectOptions() []client.ConnectOption {
+	return []client.ConnectOption{
+		client.WithEvaluateTimeout(t.Evaluate),
+		client.WithEndorseTimeout(t.Endorse),
+		client.WithSubmitTimeout(t.Submit),
+		client.WithCommitStatusTimeout(t.CommitStatus),
+	}
+}
+
+func resolvePath(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// Endpoints returns the peers to connect through, in order of preference.
+// Peers listed in the config share its TLS CA certificate and host
+// override; otherwise the peers come from a connection profile.
+func (o *OrgConfig) Endpoints() ([]PeerEndpoint, error) {
+	if len(o.Peers) == 0 {
+		return o.endpoints, nil
+	}
+
+	certificatePEM, err := os.ReadFile(o.TLSCACert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS certificate: %w", err)
+	}
+
+	endpoints := make([]PeerEndpoint, 0, len(o.Peers))
+	for _, peer := range o.Peers {
+		endpoints = append(endpoints, PeerEndpoint{
+			Name:         peer,
+			Address:      peer,
+			TLSCACertPEM: certificatePEM,
+			HostOverride: o.HostOverride,
+		})
+	}
+	return endpoints, nil
+}
+
+// Organization returns the configuration for an MSP ID.
+func (c *Config) Organization(mspID string) (*OrgConfig, error) {
+	org, ok := c.Organizations[mspID]
+	if !ok {
+		return nil, fmt.Errorf("unknown MSP %s", mspID)
+	}
+	return org, nil
+}
+
+// caConfig returns the CA to re-enroll an MSP's identities against.
+func (c *Config) caConfig(mspID string) (*CAConfig, bool) {
+	org, ok := c.Organizations[mspID]
+	if !ok || org.CA == nil || org.CA.URL == "" {
+		return nil, false
+	}
+	return org.CA, true
+}
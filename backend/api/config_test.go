@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testConfigYAML = `
+channel: channel1
+chaincode: basic
+organizations:
+  Org1MSP:
+    peers: [localhost:7051]
+    tlsCACert: tls/org1.pem
+  Org2MSP:
+    peers: [localhost:9051]
+    tlsCACert: /abs/org2.pem
+    chaincode: org2cc
+    ca:
+      url: https://localhost:8054/
+      name: ca-org2
+`
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("API_CONFIG", path)
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeTestConfig(t, testConfigYAML)
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+
+	org1, err := config.Organization("Org1MSP")
+	if err != nil {
+		t.Fatalf("expected Org1MSP to be configured: %v", err)
+	}
+	if org1.Channel != "channel1" || org1.Chaincode != "basic" {
+		t.Errorf("expected Org1MSP to inherit channel and chaincode, got %s/%s", org1.Channel, org1.Chaincode)
+	}
+	if org1.TLSCACert != filepath.Join(filepath.Dir(path), "tls/org1.pem") {
+		t.Errorf("expected TLS CA path relative to the config file, got %s", org1.TLSCACert)
+	}
+
+	org2, _ := config.Organization("Org2MSP")
+	if org2.Chaincode != "org2cc" || org2.TLSCACert != "/abs/org2.pem" {
+		t.Errorf("unexpected Org2MSP config: %+v", org2)
+	}
+	if ca, ok := config.caConfig("Org2MSP"); !ok || ca.URL != "https://localhost:8054" {
+		t.Errorf("expected Org2MSP CA config, got %+v", ca)
+	}
+	if _, ok := config.caConfig("Org1MSP"); ok {
+		t.Errorf("expected no CA config for Org1MSP")
+	}
+	if _, err := config.Organization("Org3MSP"); err == nil {
+		t.Errorf("expected an error for an unknown MSP")
+	}
+}
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	writeTestConfig(t, testConfigYAML)
+	t.Setenv("FABRIC_CHANNEL", "channel2")
+	t.Setenv("FABRIC_ORG_ORG1MSP_PEERS", "peer0:7051, peer1:7051")
+	t.Setenv("FABRIC_ORG_ORG1MSP_HOST_OVERRIDE", "peer0.org1.av.com")
+	t.Setenv("FABRIC_CA_ORG1MSP_URL", "https://ca.org1:7054")
+	t.Setenv("FABRIC_TIMEOUT_ENDORSE", "30s")
+	t.Setenv("CERT_EXPIRY_WARN_DAYS", "14")
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+
+	org1, _ := config.Organization("Org1MSP")
+	if org1.Channel != "channel2" {
+		t.Errorf("expected channel override, got %s", org1.Channel)
+	}
+	if len(org1.Peers) != 2 || org1.Peers[1] != "peer1:7051" {
+		t.Errorf("expected peers override, got %v", org1.Peers)
+	}
+	if org1.HostOverride != "peer0.org1.av.com" {
+		t.Errorf("expected host override, got %s", org1.HostOverride)
+	}
+	if ca, ok := config.caConfig("Org1MSP"); !ok || ca.URL != "https://ca.org1:7054" {
+		t.Errorf("expected CA override, got %+v", ca)
+	}
+	if config.Timeouts.Endorse != 30*time.Second || config.Timeouts.Evaluate != 5*time.Second {
+		t.Errorf("expected endorse override and evaluate default, got %+v", config.Timeouts)
+	}
+	if config.Certificates.WarnDays != 14 || config.Certificates.CriticalDays != 7 || config.Certificates.Interval != time.Hour {
+		t.Errorf("expected warn days override and certificate defaults, got %+v", config.Certificates)
+	}
+}
+
+func TestLoadConfigOrganizationFromEnv(t *testing.T) {
+	writeTestConfig(t, "channel: channel1\nchaincode: basic\n")
+	t.Setenv("FABRIC_ORGS", "Org3MSP")
+	t.Setenv("FABRIC_ORG_ORG3MSP_PEERS", "peer0.org3:11051")
+	t.Setenv("FABRIC_ORG_ORG3MSP_TLS_CA_CERT", "/abs/org3.pem")
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	org3, err := config.Organization("Org3MSP")
+	if err != nil {
+		t.Fatalf("expected Org3MSP to be configured: %v", err)
+	}
+	if len(org3.Peers) != 1 || org3.TLSCACert != "/abs/org3.pem" || org3.Channel != "channel1" {
+		t.Errorf("unexpected Org3MSP config: %+v", org3)
+	}
+}
+
+func TestLoadConfigRejectsIncompleteOrganization(t *testing.T) {
+	writeTestConfig(t, `
+channel: channel1
+chaincode: basic
+organizations:
+  Org1MSP:
+    tlsCACert: org1.pem
+`)
+	if _, err := loadConfig(); err == nil {
+		t.Fatalf("expected an organization without peers to be rejected")
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// updateGrpcConnection connects to the first reachable peer of the
+// organization, trying its endpoints in order.
+func updateGrpcConnection(org *OrgConfig) (*grpc.ClientConn, PeerEndpoint, error) {
+	endpoints, err := org.Endpoints()
+	if err != nil {
+		return nil, PeerEndpoint{}, err
+	}
+
+	var errs []error
+	for _, endpoint := range endpoints {
+		connection, err := dialPeer(endpoint, appConfig.ConnectTimeout)
+		if err == nil {
+			log.Printf("Connected to peer %s (%s)", endpoint.Name, endpoint.Address)
+			return connection, endpoint, nil
+		}
+		log.Printf("Peer %s is unavailable, trying next: %v", endpoint.Name, err)
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint.Name, err))
+	}
+	return nil, PeerEndpoint{}, fmt.Errorf("no reachable peer for %s: %w", org.MSPID, errors.Join(errs...))
+}
+
+func transportCredentials(endpoint PeerEndpoint) (grpc.DialOption, error) {
+	if endpoint.Insecure {
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(endpoint.TLSCACertPEM) {
+		return nil, fmt.Errorf("failed to add certificate to pool")
+	}
+	return grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, endpoint.HostOverride)), nil
+}
+
+// dialPeer opens a connection and waits until it is ready, failing as soon
+// as the first connection attempt fails or the timeout expires.
+func dialPeer(endpoint PeerEndpoint, timeout time.Duration) (*grpc.ClientConn, error) {
+	credentialsOption, err := transportCredentials(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []grpc.DialOption{credentialsOption}
+	if appConfig.KeepaliveTime > 0 {
+		// Peers reject pings more frequent than their keepalive.minInterval
+		// (60s by default), so KeepaliveTime must not go below that.
+		options = append(options, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                appConfig.KeepaliveTime,
+			Timeout:             appConfig.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
+
+	connection, err := grpc.NewClient(endpoint.Address, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	connection.Connect()
+	for {
+		state := connection.GetState()
+		switch state {
+		case connectivity.Ready:
+			return connection, nil
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			connection.Close()
+			return nil, fmt.Errorf("connection to %s failed", endpoint.Address)
+		}
+		if !connection.WaitForStateChange(ctx, state) {
+			connection.Close()
+			return nil, fmt.Errorf("timed out connecting to %s", endpoint.Address)
+		}
+	}
+}
+
+// peerConnection is the connection the gateway is built on. It holds a
+// connection to one of the organization's peers and, when that peer stops
+// answering, reconnects in the background with exponential backoff, moving
+// to another peer if needed. Calls made in the meantime fail fast.
+type peerConnection struct {
+	org    *OrgConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.RWMutex
+	conn       *grpc.ClientConn
+	endpoint   PeerEndpoint
+	reconnects int
+	lastErr    error
+}
+
+// PeerStatus describes the peer connection for the health endpoints.
+type PeerStatus struct {
+	Peer       string `json:"peer"`
+	Address    string `json:"address"`
+	State      string `json:"state"`
+	Reconnects int    `json:"reconnects"`
+	LastError  string `json:"lastError,omitempty"`
+}
+
+func newPeerConnection(org *OrgConfig) (*peerConnection, error) {
+	conn, endpoint, err := updateGrpcConnection(org)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &peerConnection{org: org, ctx: ctx, cancel: cancel, conn: conn, endpoint: endpoint}
+	go p.monitor()
+	return p, nil
+}
+
+func (p *peerConnection) current() *grpc.ClientConn {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.conn
+}
+
+func (p *peerConnection) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	return p.current().Invoke(ctx, method, args, reply, opts...)
+}
+
+func (p *peerConnection) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.current().NewStream(ctx, desc, method, opts...)
+}
+
+// Close stops reconnecting and closes the underlying connection.
+func (p *peerConnection) Close() error {
+	p.cancel()
+	return p.current().Close()
+}
+
+func (p *peerConnection) Status() PeerStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := PeerStatus{
+		Peer:       p.endpoint.Name,
+		Address:    p.endpoint.Address,
+		State:      p.conn.GetState().String(),
+		Reconnects: p.reconnects,
+	}
+	if p.lastErr != nil {
+		status.LastError = p.lastErr.Error()
+	}
+	return status
+}
+
+// Ready reports whether the peer connection can currently carry calls.
+func (p *peerConnection) Ready() bool {
+	return p.current().GetState() == connectivity.Ready
+}
+
+// monitor follows the connection state. An idle connection is woken up so
+// a dead peer is noticed before the next request, and a failing one is
+// replaced by a fresh connection to the first reachable peer.
+func (p *peerConnection) monitor() {
+	backoff := appConfig.ReconnectBackoff
+	for {
+		conn := p.current()
+		state := conn.GetState()
+
+		switch state {
+		case connectivity.Idle:
+			conn.Connect()
+		case connectivity.TransientFailure:
+			if p.reconnect() {
+				backoff = appConfig.ReconnectBackoff
+				continue
+			}
+			select {
+			case <-time.After(backoff):
+			case <-p.ctx.Done():
+				return
+			}
+			backoff *= 2
+			if backoff > appConfig.ReconnectMaxBackoff {
+				backoff = appConfig.ReconnectMaxBackoff
+			}
+			continue
+		}
+
+		if !conn.WaitForStateChange(p.ctx, state) {
+			return
+		}
+	}
+}
+
+// reconnect dials the organization's peers again and swaps in the new
+// connection when one of them answers.
+func (p *peerConnection) reconnect() bool {
+	conn, endpoint, err := updateGrpcConnection(p.org)
+	if err != nil {
+		log.Printf("Reconnect to %s failed: %v", p.org.MSPID, err)
+		p.mu.Lock()
+		p.lastErr = err
+		p.mu.Unlock()
+		return false
+	}
+
+	p.mu.Lock()
+	if p.ctx.Err() != nil {
+		p.mu.Unlock()
+		conn.Close()
+		return false
+	}
+	previous := p.conn
+	p.conn, p.endpoint = conn, endpoint
+	p.reconnects++
+	p.lastErr = nil
+	p.mu.Unlock()
+
+	previous.Close()
+	log.Printf("Reconnected to peer %s (%s)", endpoint.Name, endpoint.Address)
+	return true
+}
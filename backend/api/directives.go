@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// publishDirective publishes an Airworthiness Directive or Service Bulletin.
+// The body is the directive as JSON; only the regulator may publish.
+func publishDirective(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil || len(body) == 0 {
+		respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, "Invalid request payload")
+		return
+	}
+
+	response, err := getContract().SubmitWithContext(c.Request.Context(), "PublishDirective", client.WithArguments(string(body)))
+	if err != nil {
+		respondGatewayError(c, "Failed to publish directive", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+func getDirectives(c *gin.Context) {
+	response, err := getContract().EvaluateWithContext(c.Request.Context(), "GetAllDirectives")
+	if err != nil {
+		respondGatewayError(c, "Failed to query chaincode", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+func getDirective(c *gin.Context) {
+	response, err := getContract().EvaluateWithContext(c.Request.Context(), "GetDirective", client.WithArguments(c.Param("id")))
+	if err != nil {
+		respondGatewayError(c, "Failed to query chaincode", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+// recordDirectiveCompliance records that an aircraft complied with a
+// directive, optionally citing the asset reporting the work.
+func recordDirectiveCompliance(c *gin.Context) {
+	var request struct {
+		Date    string `json:"date"`
+		Method  string `json:"method"`
+		AssetID string `json:"asset_id"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, "Invalid request payload")
+		return
+	}
+	if request.Date == "" || request.Method == "" {
+		respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, "date and method are required")
+		return
+	}
+
+	args := []string{c.Param("id"), c.Param("directive"), request.Date, request.Method}
+	if request.AssetID != "" {
+		args = append(args, request.AssetID)
+	}
+	response, err := getContract().SubmitWithContext(c.Request.Context(), "RecordDirectiveCompliance", client.WithArguments(args...))
+	if err != nil {
+		respondGatewayError(c, "Failed to record directive compliance", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+func getDirectiveCompliance(c *gin.Context) {
+	response, err := getContract().EvaluateWithContext(c.Request.Context(), "GetDirectiveCompliance", client.WithArguments(c.Param("id")))
+	if err != nil {
+		respondGatewayError(c, "Failed to query chaincode", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+// getOutstandingDirectives returns the directives the registered type of
+// an aircraft still has to comply with.
+func getOutstandingDirectives(c *gin.Context) {
+	response, err := getContract().EvaluateWithContext(c.Request.Context(), "GetOutstandingDirectives", client.WithArguments(c.Param("id")))
+	if err != nil {
+		respondGatewayError(c, "Failed to query chaincode", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
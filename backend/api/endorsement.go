@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// assetProposalOptions returns the options of a transaction changing an
+// asset: its arguments and, when the asset has a key-level endorsement
+// policy, the organizations that policy requires. Without them the gateway
+// would pick endorsers for the chaincode-level policy only and the
+// transaction would fail validation.
+func assetProposalOptions(ctx context.Context, contract *client.Contract, id string, args ...string) ([]client.ProposalOption, error) {
+	options := []client.ProposalOption{client.WithArguments(args...)}
+
+	response, err := contract.EvaluateWithContext(ctx, "GetEndorsingOrgs", client.WithArguments(id))
+	if err != nil {
+		return nil, err
+	}
+	var orgs []string
+	if err := json.Unmarshal(response, &orgs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal endorsing organizations: %v", err)
+	}
+	if len(orgs) > 0 {
+		options = append(options, client.WithEndorsingOrganizations(orgs...))
+	}
+	return options, nil
+}
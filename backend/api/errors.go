@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	gatewaypb "github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusClientClosedRequest is returned when the HTTP client went away
+// before the gateway call finished; nobody reads the response.
+const statusClientClosedRequest = 499
+
+// Error codes of the API error schema. Clients should switch on these
+// rather than on the message.
+const (
+	ErrCodeNotFound           = "NOT_FOUND"
+	ErrCodeAlreadyExists      = "ALREADY_EXISTS"
+	ErrCodeAccessDenied       = "ACCESS_DENIED"
+	ErrCodeInvalidArgument    = "INVALID_ARGUMENT"
+	ErrCodeTimeout            = "TIMEOUT"
+	ErrCodeCancelled          = "CANCELLED"
+	ErrCodeUnavailable        = "UNAVAILABLE"
+	ErrCodeEndorsementFailed  = "ENDORSEMENT_FAILED"
+	ErrCodeSubmitFailed       = "SUBMIT_FAILED"
+	ErrCodeCommitStatusFailed = "COMMIT_STATUS_FAILED"
+	ErrCodeCommitConflict     = "COMMIT_CONFLICT"
+	ErrCodeCommitFailed       = "COMMIT_FAILED"
+	ErrCodeOracleConflict     = "ORACLE_CONFLICT"
+	ErrCodeInternal           = "INTERNAL"
+)
+
+// APIError is the body of every error response. The gateway fields are set
+// for a failed gateway call.
+type APIError struct {
+	Error          string            `json:"error"`
+	Code           string            `json:"code"`
+	TransactionID  string            `json:"transactionId,omitempty"`
+	ValidationCode string            `json:"validationCode,omitempty"`
+	Status         *FabricStatus     `json:"status,omitempty"`
+	Endorsements   []PeerErrorDetail `json:"endorsements,omitempty"`
+}
+
+// FabricStatus is the gRPC status of a failed gateway call.
+type FabricStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PeerErrorDetail is the error one peer returned for a gateway call.
+type PeerErrorDetail struct {
+	Address string `json:"address"`
+	MspID   string `json:"mspId"`
+	Message string `json:"message"`
+}
+
+// chaincodeResponse matches the prefix peers put in front of the message a
+// chaincode returned with shim.Error.
+var chaincodeResponse = regexp.MustCompile(`chaincode response \d+, `)
+
+// chaincodeMessage returns the part of a peer message written by the
+// chaincode, or the whole message when there is none.
+func chaincodeMessage(message string) string {
+	if loc := chaincodeResponse.FindAllStringIndex(message, -1); loc != nil {
+		return message[loc[len(loc)-1][1]:]
+	}
+	return message
+}
+
+// translateError maps an error from the gateway client onto an HTTP status
+// and the API error schema. message says what the handler was doing.
+func translateError(message string, err error) (int, APIError) {
+	httpStatus := http.StatusInternalServerError
+	apiErr := APIError{Code: ErrCodeInternal}
+
+	var endorseErr *client.EndorseError
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	var commitErr *client.CommitError
+	switch {
+	case errors.As(err, &endorseErr):
+		apiErr.Code, apiErr.TransactionID = ErrCodeEndorsementFailed, endorseErr.TransactionID
+	case errors.As(err, &submitErr):
+		httpStatus = http.StatusBadGateway
+		apiErr.Code, apiErr.TransactionID = ErrCodeSubmitFailed, submitErr.TransactionID
+	case errors.As(err, &commitStatusErr):
+		httpStatus = http.StatusBadGateway
+		apiErr.Code, apiErr.TransactionID = ErrCodeCommitStatusFailed, commitStatusErr.TransactionID
+	case errors.As(err, &commitErr):
+		apiErr.TransactionID = commitErr.TransactionID
+		apiErr.ValidationCode = commitErr.Code.String()
+		apiErr.Error = fmt.Sprintf("%s: transaction was not committed (%s)", message, apiErr.ValidationCode)
+		switch commitErr.Code {
+		case peer.TxValidationCode_MVCC_READ_CONFLICT, peer.TxValidationCode_PHANTOM_READ_CONFLICT:
+			return http.StatusConflict, withCode(apiErr, ErrCodeCommitConflict)
+		case peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE:
+			return http.StatusForbidden, withCode(apiErr, ErrCodeAccessDenied)
+		default:
+			return http.StatusUnprocessableEntity, withCode(apiErr, ErrCodeCommitFailed)
+		}
+	}
+
+	// Only messages from the gateway and its peers are passed on. Any other
+	// error is internal to the API, its text is logged but not returned.
+	reason, lower := "", ""
+	if grpcStatus, ok := status.FromError(err); ok {
+		apiErr.Status = &FabricStatus{Code: grpcStatus.Code().String(), Message: grpcStatus.Message()}
+		reason = chaincodeMessage(grpcStatus.Message())
+		for _, detail := range grpcStatus.Details() {
+			if peerError, ok := detail.(*gatewaypb.ErrorDetail); ok {
+				apiErr.Endorsements = append(apiErr.Endorsements, PeerErrorDetail{
+					Address: peerError.GetAddress(),
+					MspID:   peerError.GetMspId(),
+					Message: chaincodeMessage(peerError.GetMessage()),
+				})
+			}
+		}
+		if len(apiErr.Endorsements) > 0 {
+			reason = apiErr.Endorsements[0].Message
+		}
+		lower = strings.ToLower(reason)
+	}
+
+	code := status.Code(err)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || code == codes.DeadlineExceeded:
+		httpStatus, apiErr.Code = http.StatusGatewayTimeout, ErrCodeTimeout
+	case errors.Is(err, context.Canceled) || code == codes.Canceled:
+		httpStatus, apiErr.Code = statusClientClosedRequest, ErrCodeCancelled
+	case code == codes.Unavailable:
+		httpStatus, apiErr.Code = http.StatusServiceUnavailable, ErrCodeUnavailable
+	case code == codes.PermissionDenied || strings.Contains(lower, "access denied") ||
+		strings.Contains(lower, "permission denied") || strings.Contains(lower, "not authorized"):
+		httpStatus, apiErr.Code = http.StatusForbidden, ErrCodeAccessDenied
+	case strings.Contains(lower, "does not exist") || strings.Contains(lower, "no such transaction"):
+		httpStatus, apiErr.Code = http.StatusNotFound, ErrCodeNotFound
+	case strings.Contains(lower, "already exists"):
+		httpStatus, apiErr.Code = http.StatusConflict, ErrCodeAlreadyExists
+	case code == codes.InvalidArgument || strings.Contains(lower, "incorrect number of arguments"):
+		httpStatus, apiErr.Code = http.StatusBadRequest, ErrCodeInvalidArgument
+	}
+
+	if reason == "" {
+		reason = internalReasons[apiErr.Code]
+	}
+	apiErr.Error = message
+	if reason != "" {
+		apiErr.Error = fmt.Sprintf("%s: %s", message, reason)
+	}
+	return httpStatus, apiErr
+}
+
+// internalReasons stand in for the text of errors that did not come from
+// the gateway.
+var internalReasons = map[string]string{
+	ErrCodeTimeout:            "the request timed out",
+	ErrCodeCancelled:          "the request was cancelled",
+	ErrCodeEndorsementFailed:  "endorsement failed",
+	ErrCodeSubmitFailed:       "the transaction could not be submitted",
+	ErrCodeCommitStatusFailed: "the commit status is unknown",
+	ErrCodeInternal:           "internal error",
+}
+
+func withCode(apiErr APIError, code string) APIError {
+	apiErr.Code = code
+	return apiErr
+}
+
+// respondError writes an error that did not come from the gateway in the
+// API error schema.
+func respondError(c *gin.Context, httpStatus int, code, message string) {
+	c.JSON(httpStatus, APIError{Error: message, Code: code})
+}
+
+// respondInternalError writes message for an unexpected error. The error
+// itself is only logged.
+func respondInternalError(c *gin.Context, message string, err error) {
+	log.Printf("%s: %v", message, err)
+	respondError(c, http.StatusInternalServerError, ErrCodeInternal, message)
+}
+
+// respondGatewayError writes the response for a failed gateway call. The
+// raw error is only logged.
+func respondGatewayError(c *gin.Context, message string, err error) {
+	httpStatus, apiErr := translateError(message, err)
+	log.Printf("%s: %v", message, err)
+
+	if apiErr.Code == ErrCodeCancelled && c.Request.Context().Err() != nil {
+		c.AbortWithStatus(httpStatus)
+		return
+	}
+	c.JSON(httpStatus, apiErr)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	gatewaypb "github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func endorsementStatus(t *testing.T, code codes.Code, peerMessage string) error {
+	t.Helper()
+	grpcStatus, err := status.New(code, "evaluate call to endorser returned error: "+peerMessage).WithDetails(&gatewaypb.ErrorDetail{
+		Address: "peer0.org1.av.com:7051",
+		MspId:   "Org1MSP",
+		Message: peerMessage,
+	})
+	if err != nil {
+		t.Fatalf("failed to build status: %v", err)
+	}
+	return grpcStatus.Err()
+}
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		httpStatus int
+		code       string
+		message    string
+	}{
+		{
+			name:       "not found",
+			err:        endorsementStatus(t, codes.Unknown, "chaincode response 500, Asset asset9 does not exist"),
+			httpStatus: http.StatusNotFound,
+			code:       ErrCodeNotFound,
+			message:    "Failed to query chaincode: Asset asset9 does not exist",
+		},
+		{
+			name:       "already exists",
+			err:        endorsementStatus(t, codes.Aborted, "chaincode response 500, Asset asset1 already exists"),
+			httpStatus: http.StatusConflict,
+			code:       ErrCodeAlreadyExists,
+			message:    "Failed to query chaincode: Asset asset1 already exists",
+		},
+		{
+			name:       "access denied",
+			err:        endorsementStatus(t, codes.Unknown, "access denied: channel [channel1] creator org unknown"),
+			httpStatus: http.StatusForbidden,
+			code:       ErrCodeAccessDenied,
+		},
+		{
+			name:       "timeout",
+			err:        endorsementStatus(t, codes.DeadlineExceeded, "context deadline exceeded"),
+			httpStatus: http.StatusGatewayTimeout,
+			code:       ErrCodeTimeout,
+		},
+		{
+			name:       "unavailable",
+			err:        status.Error(codes.Unavailable, "connection refused"),
+			httpStatus: http.StatusServiceUnavailable,
+			code:       ErrCodeUnavailable,
+		},
+		{
+			name:       "wrapped deadline",
+			err:        fmt.Errorf("failed: %w", context.DeadlineExceeded),
+			httpStatus: http.StatusGatewayTimeout,
+			code:       ErrCodeTimeout,
+			message:    "Failed to query chaincode: the request timed out",
+		},
+		{
+			name:       "internal not found",
+			err:        errors.New("open /var/wallet/asset9: file does not exist"),
+			httpStatus: http.StatusInternalServerError,
+			code:       ErrCodeInternal,
+			message:    "Failed to query chaincode: internal error",
+		},
+		{
+			name:       "read conflict",
+			err:        &client.CommitError{TransactionID: "tx1", Code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+			httpStatus: http.StatusConflict,
+			code:       ErrCodeCommitConflict,
+			message:    "Failed to query chaincode: transaction was not committed (MVCC_READ_CONFLICT)",
+		},
+		{
+			name:       "other",
+			err:        errors.New("boom"),
+			httpStatus: http.StatusInternalServerError,
+			code:       ErrCodeInternal,
+			message:    "Failed to query chaincode: internal error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpStatus, apiErr := translateError("Failed to query chaincode", test.err)
+			if httpStatus != test.httpStatus || apiErr.Code != test.code {
+				t.Errorf("expected %d %s, got %d %s", test.httpStatus, test.code, httpStatus, apiErr.Code)
+			}
+			if test.message != "" && apiErr.Error != test.message {
+				t.Errorf("expected message %q, got %q", test.message, apiErr.Error)
+			}
+		})
+	}
+}
+
+func TestRespondGatewayErrorSchema(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/read_asset/asset1", nil)
+	respondGatewayError(c, "Failed to query chaincode", endorsementStatus(t, codes.DeadlineExceeded, "chaincode did not respond"))
+
+	if recorder.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", recorder.Code)
+	}
+	var body APIError
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Code != ErrCodeTimeout || body.Status == nil || body.Status.Code != "DeadlineExceeded" {
+		t.Errorf("expected timeout with Fabric status, got %+v", body)
+	}
+	if len(body.Endorsements) != 1 || body.Endorsements[0].MspID != "Org1MSP" || body.Endorsements[0].Message != "chaincode did not respond" {
+		t.Errorf("expected the peer's endorsement message, got %+v", body.Endorsements)
+	}
+}
+
+func TestRespondInternalErrorHidesError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	respondInternalError(c, "Failed to unmarshal response", errors.New("invalid character 'x' in /var/wallet"))
+
+	var body APIError
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if recorder.Code != http.StatusInternalServerError || body.Code != ErrCodeInternal || body.Error != "Failed to unmarshal response" {
+		t.Errorf("expected a stable internal error, got %d %+v", recorder.Code, body)
+	}
+}
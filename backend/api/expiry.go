@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+const (
+	assetStatusDue     = "due"
+	assetStatusOverdue = "overdue"
+	assetStatusExpired = "expired"
+)
+
+// chaincodeStatusExpired is the status MarkExpired gives an asset.
+const chaincodeStatusExpired = "Expired"
+
+const dueDateLayout = "2006-01-02"
+
+// ExpiryConfig drives the scheduler that tracks when assets fall due.
+// Assets due within WarnDays are reported as due, assets past their due date
+// as overdue. With MarkExpired overdue assets are marked Expired on the
+// ledger. Every change is posted to the Webhooks.
+type ExpiryConfig struct {
+	Interval    time.Duration `yaml:"interval"`
+	WarnDays    int           `yaml:"warnDays"`
+	MarkExpired bool          `yaml:"markExpired"`
+	Webhooks    []string      `yaml:"webhooks"`
+}
+
+// DueAsset is an asset nearing or past its due date.
+type DueAsset struct {
+	Asset     Asset  `json:"asset"`
+	Status    string `json:"status"`
+	DaysToDue int    `json:"daysToDue"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ExpiryEvent is posted to the webhooks when an asset becomes due, overdue
+// or expired.
+type ExpiryEvent struct {
+	Type      string    `json:"type"`
+	Asset     Asset     `json:"asset"`
+	DaysToDue int       `json:"daysToDue"`
+	Time      time.Time `json:"time"`
+}
+
+// ExpiryMonitor periodically queries the assets nearing or past their due
+// date, notifies the webhooks and optionally marks overdue assets Expired.
+type ExpiryMonitor struct {
+	config      ExpiryConfig
+	dueAssets   func(ctx context.Context, before string) ([]Asset, error)
+	markExpired func(ctx context.Context, id string) (*Asset, error)
+	client      *http.Client
+	now         func() time.Time
+
+	mu       sync.RWMutex
+	assets   []DueAsset
+	lastScan time.Time
+	notified map[string]string
+}
+
+func NewExpiryMonitor(config ExpiryConfig) *ExpiryMonitor {
+	return &ExpiryMonitor{
+		config: config,
+		dueAssets: func(ctx context.Context, before string) ([]Asset, error) {
+			if gateway, _ := currentGateway(); gateway == nil {
+				return nil, errors.New("no wallet session, sign in first")
+			}
+			response, err := getContract().EvaluateWithContext(ctx, "GetDueAssets", client.WithArguments(before))
+			if err != nil {
+				return nil, err
+			}
+			var assets []Asset
+			if err := json.Unmarshal(response, &assets); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal assets: %v", err)
+			}
+			return assets, nil
+		},
+		markExpired: func(ctx context.Context, id string) (*Asset, error) {
+			contract := getContract()
+			options, err := assetProposalOptions(ctx, contract, id, id)
+			if err != nil {
+				return nil, err
+			}
+			response, err := contract.SubmitWithContext(ctx, "MarkExpired", options...)
+			if err != nil {
+				return nil, err
+			}
+			var asset Asset
+			if err := json.Unmarshal(response, &asset); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal asset: %v", err)
+			}
+			return &asset, nil
+		},
+		client:   &http.Client{Timeout: 10 * time.Second},
+		now:      time.Now,
+		notified: make(map[string]string),
+	}
+}
+
+// Run scans immediately and then on every interval until stop is closed.
+func (m *ExpiryMonitor) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.Scan(context.Background())
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Scan queries the assets due within the warning period and returns them.
+func (m *ExpiryMonitor) Scan(ctx context.Context) []DueAsset {
+	now := m.now().UTC()
+	today := now.Format(dueDateLayout)
+	before := now.AddDate(0, 0, m.config.WarnDays).Format(dueDateLayout)
+
+	assets, err := m.dueAssets(ctx, before)
+	if err != nil {
+		log.Printf("Expiry monitor failed to query due assets: %v", err)
+		return m.DueAssets()
+	}
+
+	dueAssets := make([]DueAsset, 0, len(assets))
+	current := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		due := m.check(ctx, asset, today)
+		dueAssets = append(dueAssets, due)
+		current[notifyKey(due)] = true
+	}
+
+	m.mu.Lock()
+	m.assets = dueAssets
+	m.lastScan = now
+	// Forget assets no longer due, such as those renewed with a later due
+	// date, so the map does not grow and they are notified if due again.
+	for key := range m.notified {
+		if !current[key] {
+			delete(m.notified, key)
+		}
+	}
+	m.mu.Unlock()
+
+	return dueAssets
+}
+
+func (m *ExpiryMonitor) check(ctx context.Context, asset Asset, today string) DueAsset {
+	due := DueAsset{Asset: asset, DaysToDue: daysBetween(today, asset.DueDate)}
+	switch {
+	case asset.Status == chaincodeStatusExpired:
+		due.Status = assetStatusExpired
+	case asset.DueDate < today:
+		due.Status = assetStatusOverdue
+	default:
+		due.Status = assetStatusDue
+	}
+
+	if due.Status == assetStatusOverdue && m.config.MarkExpired {
+		expired, err := m.markExpired(ctx, asset.ID)
+		if err != nil {
+			due.Error = fmt.Sprintf("failed to mark expired: %v", err)
+			log.Printf("Failed to mark asset %s expired: %v", asset.ID, err)
+		} else {
+			due.Asset, due.Status = *expired, assetStatusExpired
+			log.Printf("Marked asset %s expired, it was due on %s", asset.ID, asset.DueDate)
+		}
+	}
+
+	m.notify(due)
+	return due
+}
+
+// notify posts an event when the status of an asset changed since the last
+// scan, so each webhook hears about each change once.
+func (m *ExpiryMonitor) notify(due DueAsset) {
+	key := notifyKey(due)
+	m.mu.Lock()
+	if m.notified[key] == due.Status {
+		m.mu.Unlock()
+		return
+	}
+	m.notified[key] = due.Status
+	m.mu.Unlock()
+
+	if due.Status != assetStatusDue {
+		log.Printf("WARNING: asset %s (%s) is %s, due on %s", due.Asset.ID, due.Asset.AircraftID, due.Status, due.Asset.DueDate)
+	}
+
+	event := ExpiryEvent{Type: "asset." + due.Status, Asset: due.Asset, DaysToDue: due.DaysToDue, Time: m.now().UTC()}
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal expiry event: %v", err)
+		return
+	}
+	for _, url := range m.config.Webhooks {
+		if err := postWebhook(m.client, url, body); err != nil {
+			log.Printf("Failed to deliver %s event for asset %s to %s: %v", event.Type, due.Asset.ID, url, err)
+		}
+	}
+}
+
+func notifyKey(due DueAsset) string {
+	return due.Asset.ID + "@" + due.Asset.DueDate
+}
+
+// postWebhook posts a JSON event to a webhook.
+func postWebhook(httpClient *http.Client, url string, body []byte) error {
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook answered with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// DueAssets returns the result of the most recent scan.
+func (m *ExpiryMonitor) DueAssets() []DueAsset {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]DueAsset(nil), m.assets...)
+}
+
+func (m *ExpiryMonitor) writeMetrics(w io.Writer) {
+	counts := map[string]int{assetStatusDue: 0, assetStatusOverdue: 0, assetStatusExpired: 0}
+	for _, due := range m.DueAssets() {
+		counts[due.Status]++
+	}
+
+	samples := make([]metricSample, 0, len(counts))
+	for _, status := range []string{assetStatusDue, assetStatusOverdue, assetStatusExpired} {
+		samples = append(samples, metricSample{
+			Labels: map[string]string{"status": status},
+			Value:  float64(counts[status]),
+		})
+	}
+	writeMetric(w, "assets_due", "gauge",
+		"Assets due within the warning period, past due or expired.", samples)
+}
+
+// getDueAssets returns the result of the most recent scan.
+func (m *ExpiryMonitor) getDueAssets(c *gin.Context) {
+	m.respondDueAssets(c, m.DueAssets())
+}
+
+// scanDueAssets scans now rather than on the next interval. Like a scheduled
+// scan it may mark assets Expired and notify the webhooks.
+func (m *ExpiryMonitor) scanDueAssets(c *gin.Context) {
+	m.respondDueAssets(c, m.Scan(c.Request.Context()))
+}
+
+func (m *ExpiryMonitor) respondDueAssets(c *gin.Context, assets []DueAsset) {
+	m.mu.RLock()
+	lastScan := m.lastScan
+	m.mu.RUnlock()
+
+	c.JSON(http.StatusOK, gin.H{"lastScan": lastScan, "warnDays": m.config.WarnDays, "assets": assets})
+}
+
+// daysBetween returns the number of days from one date to another.
+func daysBetween(from, to string) int {
+	fromDate, err := time.Parse(dueDateLayout, from)
+	if err != nil {
+		return 0
+	}
+	toDate, err := time.Parse(dueDateLayout, to)
+	if err != nil {
+		return 0
+	}
+	return int(math.Round(toDate.Sub(fromDate).Hours() / 24))
+}
+
+var validityPattern = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+// resolveDueDate returns the due date of a report: dueDate if given, or the
+// report date plus validity, such as 90d, 12w, 6m or 1y. Without either the
+// report has no due date.
+func resolveDueDate(reportDate, dueDate, validity string) (string, error) {
+	if dueDate != "" {
+		if validity != "" {
+			return "", errors.New("give either due_date or validity, not both")
+		}
+		if _, err := time.Parse(dueDateLayout, dueDate); err != nil {
+			return "", fmt.Errorf("due_date must be YYYY-MM-DD, got %q", dueDate)
+		}
+		return dueDate, nil
+	}
+	if validity == "" {
+		return "", nil
+	}
+
+	match := validityPattern.FindStringSubmatch(validity)
+	if match == nil {
+		return "", fmt.Errorf("validity must be a number of days, weeks, months or years such as 90d, 12w, 6m or 1y, got %q", validity)
+	}
+	reported, err := time.Parse(dueDateLayout, reportDate)
+	if err != nil {
+		return "", fmt.Errorf("report_date must be YYYY-MM-DD to compute a due date, got %q", reportDate)
+	}
+	n, _ := strconv.Atoi(match[1])
+	switch match[2] {
+	case "d":
+		reported = reported.AddDate(0, 0, n)
+	case "w":
+		reported = reported.AddDate(0, 0, 7*n)
+	case "m":
+		reported = reported.AddDate(0, n, 0)
+	case "y":
+		reported = reported.AddDate(n, 0, 0)
+	}
+	return reported.Format(dueDateLayout), nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResolveDueDate(t *testing.T) {
+	tests := []struct {
+		dueDate, validity, want string
+		wantErr                 bool
+	}{
+		{"", "", "", false},
+		{"2025-03-01", "", "2025-03-01", false},
+		{"", "90d", "2025-04-01", false},
+		{"", "2w", "2025-01-15", false},
+		{"", "6m", "2025-07-01", false},
+		{"", "1y", "2026-01-01", false},
+		{"2025-03-01", "6m", "", true},
+		{"01/03/2025", "", "", true},
+		{"", "six months", "", true},
+	}
+	for _, test := range tests {
+		got, err := resolveDueDate("2025-01-01", test.dueDate, test.validity)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("resolveDueDate(%q, %q) = %q, %v, want %q", test.dueDate, test.validity, got, err, test.want)
+		}
+	}
+}
+
+func TestExpiryMonitorScan(t *testing.T) {
+	var mu sync.Mutex
+	var events []ExpiryEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event ExpiryEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid webhook body: %v", err)
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	ledger := map[string]Asset{
+		"due":     {ID: "due", AircraftID: "PK-GFA", Compliance: true, DueDate: "2025-01-20"},
+		"overdue": {ID: "overdue", AircraftID: "PK-LKS", Compliance: true, DueDate: "2024-12-15"},
+		"later":   {ID: "later", AircraftID: "PK-GPA", Compliance: true, DueDate: "2025-06-01"},
+	}
+	var queried string
+	monitor := NewExpiryMonitor(ExpiryConfig{WarnDays: 30, Webhooks: []string{server.URL}})
+	monitor.now = func() time.Time { return testNow }
+	monitor.dueAssets = func(ctx context.Context, before string) ([]Asset, error) {
+		queried = before
+		var assets []Asset
+		for _, asset := range ledger {
+			if asset.DueDate <= before {
+				assets = append(assets, asset)
+			}
+		}
+		return assets, nil
+	}
+	monitor.markExpired = func(ctx context.Context, id string) (*Asset, error) {
+		asset := ledger[id]
+		asset.Status, asset.Compliance = chaincodeStatusExpired, false
+		ledger[id] = asset
+		return &asset, nil
+	}
+
+	statuses := make(map[string]DueAsset)
+	for _, due := range monitor.Scan(context.Background()) {
+		statuses[due.Asset.ID] = due
+	}
+	if queried != "2025-01-31" || len(statuses) != 2 {
+		t.Fatalf("expected the assets due by 2025-01-31, got %v for %s", statuses, queried)
+	}
+	if statuses["due"].Status != assetStatusDue || statuses["due"].DaysToDue != 19 {
+		t.Errorf("unexpected status %+v", statuses["due"])
+	}
+	if statuses["overdue"].Status != assetStatusOverdue || statuses["overdue"].DaysToDue != -17 || ledger["overdue"].Status != "" {
+		t.Errorf("expected an overdue asset left alone without markExpired, got %+v", statuses["overdue"])
+	}
+
+	// A second scan does not repeat the events, marking an asset expired does
+	monitor.config.MarkExpired = true
+	monitor.Scan(context.Background())
+	if ledger["overdue"].Status != chaincodeStatusExpired {
+		t.Errorf("expected the overdue asset to be marked expired")
+	}
+	monitor.markExpired = func(ctx context.Context, id string) (*Asset, error) {
+		return nil, errors.New("unexpected MarkExpired")
+	}
+	monitor.Scan(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	types := make(map[string]int)
+	for _, event := range events {
+		types[event.Type+" "+event.Asset.ID]++
+	}
+	if len(events) != 3 || types["asset.due due"] != 1 || types["asset.overdue overdue"] != 1 || types["asset.expired overdue"] != 1 {
+		t.Errorf("expected one event per change, got %v", types)
+	}
+}
+
+func TestExpiryMonitorForgetsAssetsNoLongerDue(t *testing.T) {
+	var events []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event ExpiryEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid webhook body: %v", err)
+		}
+		events = append(events, event.Type+" "+event.Asset.ID)
+	}))
+	defer server.Close()
+
+	assets := []Asset{{ID: "due", AircraftID: "PK-GFA", Compliance: true, DueDate: "2025-01-20"}}
+	monitor := NewExpiryMonitor(ExpiryConfig{WarnDays: 30, Webhooks: []string{server.URL}})
+	monitor.now = func() time.Time { return testNow }
+	monitor.dueAssets = func(ctx context.Context, before string) ([]Asset, error) {
+		return assets, nil
+	}
+
+	monitor.Scan(context.Background())
+	assets = nil
+	monitor.Scan(context.Background())
+	if len(monitor.notified) != 0 {
+		t.Errorf("expected the asset no longer due to be forgotten, got %v", monitor.notified)
+	}
+
+	// Due again, the asset is notified again
+	assets = []Asset{{ID: "due", AircraftID: "PK-GFA", Compliance: true, DueDate: "2025-01-20"}}
+	monitor.Scan(context.Background())
+	if len(events) != 2 || events[0] != "asset.due due" || events[1] != "asset.due due" {
+		t.Errorf("expected the asset notified each time it falls due, got %v", events)
+	}
+}
@@ -0,0 +1,461 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// LedgerProof identifies the transaction that wrote a record, so an export
+// can be checked against the chain later.
+type LedgerProof struct {
+	TransactionID  string    `json:"txId"`
+	BlockNumber    *uint64   `json:"blockNumber,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+	ValidationCode string    `json:"validationCode,omitempty"`
+}
+
+// ExportRecord is one asset state in an export.
+type ExportRecord struct {
+	Asset Asset       `json:"asset"`
+	Proof LedgerProof `json:"proof"`
+}
+
+// ExportMetadata describes where and when an export was taken.
+type ExportMetadata struct {
+	Title       string            `json:"title"`
+	Channel     string            `json:"channel"`
+	Chaincode   string            `json:"chaincode"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+	GeneratedBy string            `json:"generatedBy,omitempty"`
+	Filter      map[string]string `json:"filter,omitempty"`
+	Records     int               `json:"records"`
+}
+
+// assetRecord is an entry of the chaincode's GetAllAssets result.
+type assetRecord struct {
+	Asset     Asset     `json:"asset"`
+	TxID      string    `json:"txId"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// assetFilter selects the assets of an export. Empty fields match anything;
+// From and To bound the report date, inclusive.
+type assetFilter struct {
+	CompanyName string
+	AircraftID  string
+	Inspector   string
+	Compliance  *bool
+	From        string
+	To          string
+}
+
+func parseAssetFilter(c *gin.Context) (assetFilter, error) {
+	filter := assetFilter{
+		CompanyName: c.Query("company"),
+		AircraftID:  c.Query("aircraft"),
+		Inspector:   c.Query("inspector"),
+		From:        c.Query("from"),
+		To:          c.Query("to"),
+	}
+	if value := c.Query("compliance"); value != "" {
+		compliance, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("compliance must be true or false")
+		}
+		filter.Compliance = &compliance
+	}
+	for _, date := range []string{filter.From, filter.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return filter, fmt.Errorf("from and to must be YYYY-MM-DD")
+		}
+	}
+	return filter, nil
+}
+
+func (f assetFilter) matches(asset Asset) bool {
+	switch {
+	case f.CompanyName != "" && !strings.EqualFold(asset.CompanyName, f.CompanyName):
+		return false
+	case f.AircraftID != "" && !strings.EqualFold(asset.AircraftID, f.AircraftID):
+		return false
+	case f.Inspector != "" && !strings.EqualFold(asset.Inspector, f.Inspector):
+		return false
+	case f.Compliance != nil && asset.Compliance != *f.Compliance:
+		return false
+	case f.From != "" && asset.ReportDate < f.From:
+		return false
+	case f.To != "" && asset.ReportDate > f.To:
+		return false
+	}
+	return true
+}
+
+func (f assetFilter) describe() map[string]string {
+	described := make(map[string]string)
+	for key, value := range map[string]string{
+		"company": f.CompanyName, "aircraft": f.AircraftID, "inspector": f.Inspector,
+		"from": f.From, "to": f.To,
+	} {
+		if value != "" {
+			described[key] = value
+		}
+	}
+	if f.Compliance != nil {
+		described["compliance"] = strconv.FormatBool(*f.Compliance)
+	}
+	return described
+}
+
+// proofLookupParallelism bounds the transactions an export looks up at once
+const proofLookupParallelism = 8
+
+// proofResolver adds block numbers to proofs, looking each transaction up
+// once.
+type proofResolver struct {
+	lookup func(ctx context.Context, txID string) (TransactionRecord, error)
+	cache  map[string]TransactionRecord
+}
+
+func newProofResolver() *proofResolver {
+	return &proofResolver{lookup: lookupTransaction, cache: make(map[string]TransactionRecord)}
+}
+
+// resolve looks up the transactions not looked up yet, at most
+// proofLookupParallelism at a time, so that proof answers from the cache.
+func (r *proofResolver) resolve(ctx context.Context, txIDs []string) {
+	pending := make(map[string]bool)
+	for _, txID := range txIDs {
+		if _, ok := r.cache[txID]; !ok && txID != "" {
+			pending[txID] = true
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, proofLookupParallelism)
+	for txID := range pending {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(txID string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			record := r.lookupTransaction(ctx, txID)
+			mu.Lock()
+			r.cache[txID] = record
+			mu.Unlock()
+		}(txID)
+	}
+	wg.Wait()
+}
+
+func (r *proofResolver) proof(ctx context.Context, txID string, timestamp time.Time) LedgerProof {
+	proof := LedgerProof{TransactionID: txID, Timestamp: timestamp.UTC()}
+	if txID == "" {
+		return proof
+	}
+
+	record, ok := r.cache[txID]
+	if !ok {
+		record = r.lookupTransaction(ctx, txID)
+		r.cache[txID] = record
+	}
+	proof.BlockNumber = record.BlockNumber
+	proof.ValidationCode = record.ValidationCode
+	return proof
+}
+
+func (r *proofResolver) lookupTransaction(ctx context.Context, txID string) TransactionRecord {
+	record, err := r.lookup(ctx, txID)
+	if err != nil {
+		// The transaction ID alone still identifies the record
+		log.Printf("Failed to look up block of transaction %s: %v", txID, err)
+	}
+	return record
+}
+
+func newExportMetadata(title string, records int) ExportMetadata {
+	channel, chaincode := channelAndChaincode()
+	metadata := ExportMetadata{
+		Title:       title,
+		Channel:     channel,
+		Chaincode:   chaincode,
+		GeneratedAt: time.Now().UTC(),
+		Records:     records,
+	}
+	if session := getCurrentSession(); session != nil {
+		metadata.GeneratedBy = session.MspID()
+	}
+	return metadata
+}
+
+func exportAssets(c *gin.Context) {
+	filter, err := parseAssetFilter(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, err.Error())
+		return
+	}
+
+	result, err := getContract().EvaluateWithContext(c.Request.Context(), "GetAllAssets")
+	if err != nil {
+		respondGatewayError(c, "Failed to query chaincode", err)
+		return
+	}
+
+	var assets []assetRecord
+	if err := json.Unmarshal(result, &assets); err != nil {
+		respondInternalError(c, "Failed to unmarshal assets", err)
+		return
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Asset.ID < assets[j].Asset.ID })
+
+	var matching []assetRecord
+	var txIDs []string
+	for _, asset := range assets {
+		if filter.matches(asset.Asset) {
+			matching = append(matching, asset)
+			txIDs = append(txIDs, asset.TxID)
+		}
+	}
+	resolver := newProofResolver()
+	resolver.resolve(c.Request.Context(), txIDs)
+	records := make([]ExportRecord, 0, len(matching))
+	for _, asset := range matching {
+		records = append(records, ExportRecord{
+			Asset: asset.Asset,
+			Proof: resolver.proof(c.Request.Context(), asset.TxID, asset.Timestamp),
+		})
+	}
+
+	metadata := newExportMetadata("Aviation compliance report", len(records))
+	metadata.Filter = filter.describe()
+	renderExport(c, "compliance-report", metadata, records)
+}
+
+func exportAssetHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := getContract().EvaluateWithContext(c.Request.Context(), "GetHistory", client.WithArguments(id))
+	if err != nil {
+		respondGatewayError(c, "Failed to invoke chaincode", err)
+		return
+	}
+
+	var history []AssetHistory
+	if err := json.Unmarshal(result, &history); err != nil {
+		respondInternalError(c, "Failed to unmarshal history", err)
+		return
+	}
+	if len(history) == 0 {
+		respondError(c, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("Asset %s does not exist", id))
+		return
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Timestamp.Before(history[j].Timestamp) })
+
+	txIDs := make([]string, 0, len(history))
+	for _, entry := range history {
+		txIDs = append(txIDs, entry.TxID)
+	}
+	resolver := newProofResolver()
+	resolver.resolve(c.Request.Context(), txIDs)
+	records := make([]ExportRecord, 0, len(history))
+	for _, entry := range history {
+		records = append(records, ExportRecord{
+			Asset: entry.Asset,
+			Proof: resolver.proof(c.Request.Context(), entry.TxID, entry.Timestamp),
+		})
+	}
+
+	metadata := newExportMetadata(fmt.Sprintf("Compliance history of asset %s", id), len(records))
+	renderExport(c, "asset-"+id+"-history", metadata, records)
+}
+
+// renderExport writes the records in the format asked for with ?format=
+// (csv, ndjson or pdf, default ndjson).
+func renderExport(c *gin.Context, name string, metadata ExportMetadata, records []ExportRecord) {
+	format := strings.ToLower(c.DefaultQuery("format", "ndjson"))
+
+	var contentType string
+	var write func(io.Writer, ExportMetadata, []ExportRecord) error
+	switch format {
+	case "csv":
+		contentType, write = "text/csv", writeExportCSV
+	case "ndjson", "jsonl":
+		format, contentType, write = "ndjson", "application/x-ndjson", writeExportNDJSON
+	case "pdf":
+		contentType, write = "application/pdf", writeExportPDF
+	default:
+		respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, "format must be csv, ndjson or pdf")
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	c.Header("X-Ledger-Channel", metadata.Channel)
+	c.Header("X-Ledger-Chaincode", metadata.Chaincode)
+	c.Header("X-Export-Generated-At", metadata.GeneratedAt.Format(time.RFC3339))
+	c.Status(http.StatusOK)
+	if err := write(c.Writer, metadata, records); err != nil {
+		log.Printf("Failed to write %s export: %v", format, err)
+	}
+}
+
+// exportCSVHeader names the CSV columns. Every row repeats the channel and
+// chaincode, so rows keep their provenance when copied out of the file.
+var exportCSVHeader = []string{
+	"id", "companyName", "airlineId", "aircraftId", "compliance", "reportDate",
+	"inspector", "inspectorLicense", "inspectingOrg", "checkType", "description", "review", "dueDate", "status",
+	"txId", "blockNumber", "timestamp", "validationCode", "channel", "chaincode",
+}
+
+func writeExportCSV(w io.Writer, metadata ExportMetadata, records []ExportRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportCSVHeader); err != nil {
+		return err
+	}
+	for _, record := range records {
+		asset, proof := record.Asset, record.Proof
+		if err := writer.Write([]string{
+			asset.ID, asset.CompanyName, asset.AirlineID, asset.AircraftID, strconv.FormatBool(asset.Compliance), asset.ReportDate,
+			asset.Inspector, asset.InspectorLicense, asset.InspectingOrg, asset.CheckType, asset.Description, asset.Review, asset.DueDate, asset.Status,
+			proof.TransactionID, formatBlockNumber(proof.BlockNumber),
+			proof.Timestamp.Format(time.RFC3339Nano), proof.ValidationCode,
+			metadata.Channel, metadata.Chaincode,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeExportNDJSON writes the metadata as the first line, then a line per
+// record.
+func writeExportNDJSON(w io.Writer, metadata ExportMetadata, records []ExportRecord) error {
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(struct {
+		Metadata ExportMetadata `json:"metadata"`
+	}{metadata}); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeExportPDF(w io.Writer, metadata ExportMetadata, records []ExportRecord) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(metadata.Title, true)
+	pdf.SetCreator("Aviation Compliance DApp", true)
+	pdf.SetCreationDate(metadata.GeneratedAt)
+	pdf.SetAutoPageBreak(true, 15)
+	text := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, text(metadata.Title), "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 9)
+	lines := []string{
+		fmt.Sprintf("Channel: %s    Chaincode: %s", metadata.Channel, metadata.Chaincode),
+		fmt.Sprintf("Generated at: %s", metadata.GeneratedAt.Format(time.RFC3339)),
+		fmt.Sprintf("Records: %d", metadata.Records),
+	}
+	if metadata.GeneratedBy != "" {
+		lines = append(lines, fmt.Sprintf("Generated by: %s", metadata.GeneratedBy))
+	}
+	if len(metadata.Filter) > 0 {
+		keys := make([]string, 0, len(metadata.Filter))
+		for key := range metadata.Filter {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var filter []string
+		for _, key := range keys {
+			filter = append(filter, fmt.Sprintf("%s=%s", key, metadata.Filter[key]))
+		}
+		lines = append(lines, "Filter: "+strings.Join(filter, ", "))
+	}
+	for _, line := range lines {
+		pdf.CellFormat(0, 5, text(line), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	for _, record := range records {
+		asset, proof := record.Asset, record.Proof
+		compliance := "NON-COMPLIANT"
+		if asset.Compliance {
+			compliance = "COMPLIANT"
+		}
+
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(0, 7, text(fmt.Sprintf("%s - %s", asset.ID, compliance)), "B", 1, "L", false, 0, "")
+		company, inspector := asset.CompanyName, asset.Inspector
+		if asset.AirlineID != "" {
+			company += fmt.Sprintf(" (%s)", asset.AirlineID)
+		}
+		if asset.InspectorLicense != "" {
+			inspector += fmt.Sprintf(", license %s", asset.InspectorLicense)
+		}
+		if asset.InspectingOrg != "" {
+			inspector += fmt.Sprintf(", %s", asset.InspectingOrg)
+		}
+		details := []string{
+			fmt.Sprintf("Company: %s", company),
+			fmt.Sprintf("Aircraft: %s", asset.AircraftID),
+			fmt.Sprintf("Report date: %s", asset.ReportDate),
+			fmt.Sprintf("Inspector: %s", inspector),
+		}
+		if asset.CheckType != "" {
+			details = append(details, fmt.Sprintf("Check: %s", asset.CheckType))
+		}
+		details = append(details, fmt.Sprintf("Description: %s", asset.Description))
+		if asset.DueDate != "" {
+			details = append(details, fmt.Sprintf("Due date: %s", asset.DueDate))
+		}
+		if asset.Status != "" {
+			details = append(details, fmt.Sprintf("Status: %s", asset.Status))
+		}
+		if asset.Review != "" {
+			details = append(details, fmt.Sprintf("Review: %s", asset.Review))
+		}
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.MultiCell(0, 5, text(strings.Join(details, "\n")), "", "L", false)
+
+		pdf.SetFont("Courier", "", 8)
+		pdf.MultiCell(0, 4, text(fmt.Sprintf(
+			"Transaction: %s\nBlock: %s  Validation: %s\nLedger time: %s",
+			proof.TransactionID, formatBlockNumber(proof.BlockNumber), proof.ValidationCode,
+			proof.Timestamp.Format(time.RFC3339Nano),
+		)), "", "L", false)
+		pdf.Ln(3)
+	}
+
+	return pdf.Output(w)
+}
+
+func formatBlockNumber(blockNumber *uint64) string {
+	if blockNumber == nil {
+		return ""
+	}
+	return strconv.FormatUint(*blockNumber, 10)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testExportRecords() []ExportRecord {
+	block := uint64(7)
+	timestamp := time.Date(2024, 12, 1, 8, 30, 0, 0, time.UTC)
+	return []ExportRecord{
+		{
+			Asset: Asset{ID: "asset1", CompanyName: "Garuda", AirlineID: "GIA", AircraftID: "PK-GFA", Compliance: true, ReportDate: "2024-12-01",
+				Inspector: "Inspector Y", Description: "Engine check, passed", DueDate: "2025-06-01", InspectingOrg: "Org1MSP", InspectorLicense: "AMEL-1", CheckType: "A-check"},
+			Proof: LedgerProof{TransactionID: "tx1", BlockNumber: &block, Timestamp: timestamp, ValidationCode: "VALID"},
+		},
+		{
+			Asset: Asset{ID: "asset2", CompanyName: "Lion", AircraftID: "PK-LQA", ReportDate: "2024-12-02", Inspector: "Inspector Z"},
+			Proof: LedgerProof{TransactionID: "tx2", Timestamp: timestamp},
+		},
+	}
+}
+
+func TestAssetFilter(t *testing.T) {
+	compliant := true
+	filter := assetFilter{CompanyName: "garuda", Compliance: &compliant, From: "2024-11-01", To: "2024-12-01"}
+	records := testExportRecords()
+
+	if !filter.matches(records[0].Asset) {
+		t.Errorf("expected asset1 to match %+v", filter)
+	}
+	if filter.matches(records[1].Asset) {
+		t.Errorf("expected asset2 not to match %+v", filter)
+	}
+	if described := filter.describe(); described["company"] != "garuda" || described["compliance"] != "true" || len(described) != 4 {
+		t.Errorf("unexpected filter description %v", described)
+	}
+}
+
+func TestProofResolverLooksUpEachTransactionOnce(t *testing.T) {
+	calls := 0
+	block := uint64(12)
+	resolver := &proofResolver{
+		lookup: func(ctx context.Context, txID string) (TransactionRecord, error) {
+			calls++
+			if txID == "missing" {
+				return TransactionRecord{}, errors.New("no such transaction ID")
+			}
+			return TransactionRecord{TransactionID: txID, BlockNumber: &block, ValidationCode: "VALID"}, nil
+		},
+		cache: make(map[string]TransactionRecord),
+	}
+
+	now := time.Now()
+	resolver.proof(context.Background(), "tx1", now)
+	proof := resolver.proof(context.Background(), "tx1", now)
+	if calls != 1 || proof.BlockNumber == nil || *proof.BlockNumber != 12 || proof.ValidationCode != "VALID" {
+		t.Errorf("expected one cached lookup with block 12, got %d calls and %+v", calls, proof)
+	}
+
+	proof = resolver.proof(context.Background(), "missing", now)
+	if proof.TransactionID != "missing" || proof.BlockNumber != nil {
+		t.Errorf("expected a proof without block number, got %+v", proof)
+	}
+}
+
+func TestProofResolverBoundsParallelLookups(t *testing.T) {
+	var inFlight, peak, calls int32
+	block := uint64(3)
+	resolver := &proofResolver{
+		lookup: func(ctx context.Context, txID string) (TransactionRecord, error) {
+			atomic.AddInt32(&calls, 1)
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				seen := atomic.LoadInt32(&peak)
+				if current <= seen || atomic.CompareAndSwapInt32(&peak, seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			return TransactionRecord{TransactionID: txID, BlockNumber: &block}, nil
+		},
+		cache: make(map[string]TransactionRecord),
+	}
+
+	var txIDs []string
+	for i := 0; i < 40; i++ {
+		txIDs = append(txIDs, fmt.Sprintf("tx%d", i%20), "")
+	}
+	resolver.resolve(context.Background(), txIDs)
+	if calls != 20 || peak > proofLookupParallelism {
+		t.Errorf("expected 20 lookups, at most %d at once, got %d with %d at once", proofLookupParallelism, calls, peak)
+	}
+	if proof := resolver.proof(context.Background(), "tx7", time.Now()); proof.BlockNumber == nil || calls != 20 {
+		t.Errorf("expected a resolved proof from the cache, got %+v after %d lookups", proof, calls)
+	}
+}
+
+func TestExportWriters(t *testing.T) {
+	metadata := ExportMetadata{Title: "Aviation compliance report", Channel: "channel1", Chaincode: "basic", GeneratedAt: time.Now(), Records: 2}
+	records := testExportRecords()
+
+	var buffer bytes.Buffer
+	if err := writeExportCSV(&buffer, metadata, records); err != nil {
+		t.Fatalf("CSV export failed: %v", err)
+	}
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV export: %v", err)
+	}
+	column := make(map[string]int, len(exportCSVHeader))
+	for i, name := range exportCSVHeader {
+		column[name] = i
+	}
+	if len(rows) != 3 || rows[1][column["description"]] != "Engine check, passed" || rows[1][column["txId"]] != "tx1" ||
+		rows[1][column["blockNumber"]] != "7" || rows[2][column["blockNumber"]] != "" ||
+		rows[1][column["airlineId"]] != "GIA" || rows[2][column["chaincode"]] != "basic" || rows[1][column["inspectorLicense"]] != "AMEL-1" || rows[1][column["checkType"]] != "A-check" || rows[1][column["dueDate"]] != "2025-06-01" {
+		t.Errorf("unexpected CSV export %v", rows)
+	}
+
+	buffer.Reset()
+	if err := writeExportNDJSON(&buffer, metadata, records); err != nil {
+		t.Fatalf("NDJSON export failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	var header struct {
+		Metadata ExportMetadata `json:"metadata"`
+	}
+	var first ExportRecord
+	if len(lines) != 3 || json.Unmarshal([]byte(lines[0]), &header) != nil || header.Metadata.Channel != "channel1" ||
+		json.Unmarshal([]byte(lines[1]), &first) != nil || first.Proof.TransactionID != "tx1" {
+		t.Errorf("unexpected NDJSON export %q", buffer.String())
+	}
+
+	buffer.Reset()
+	if err := writeExportPDF(&buffer, metadata, records); err != nil {
+		t.Fatalf("PDF export failed: %v", err)
+	}
+	if !bytes.HasPrefix(buffer.Bytes(), []byte("%PDF-")) {
+		t.Errorf("expected a PDF document")
+	}
+}
+
+func TestExportRoutesDoNotConflict(t *testing.T) {
+	router := gin.New()
+	router.POST("/assets/bulk", bulkCreateAssets)
+	router.GET("/assets/export", exportAssets)
+	router.GET("/assets/:id/export", exportAssetHistory)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"aviation-compliance-dapp-oracle/oracle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// aircraftRegistry resolves aircraft to their operator of record. It is nil
+// when no registry dataset is configured, and reports can then only be
+// filed with the consensus provider.
+var aircraftRegistry *oracle.Registry
+
+// errNoAircraftRegistry is returned by lookupCompany when nothing is
+// configured that resolves an aircraft to its operator.
+var errNoAircraftRegistry = errors.New("no aircraft registry is configured, set oracle.registry.file or ORACLE_REGISTRY_FILE")
+
+// oracleSigner signs the oracle answers stored with new assets. Without a
+// signing key assets are created without an attestation.
+var oracleSigner *oracle.Signer
+
+// setupOracle creates the flight data provider, opens the aircraft
+// registry and loads the oracle signing key configured in appConfig. The
+// sources of the consensus provider must look up aircraft, as flight
+// providers would take a tail number for a flight number.
+func setupOracle() error {
+	if appConfig.Oracle.Provider == "consensus" {
+		for _, source := range appConfig.Oracle.Consensus.Sources {
+			if !oracle.AircraftProvider(source) {
+				return fmt.Errorf("oracle source %s looks up flights, not aircraft: only registry and static sources can vote on the operator of an aircraft", source)
+			}
+		}
+	}
+
+	var err error
+	flightProvider, err = oracle.NewProvider(appConfig.Oracle)
+	if err != nil {
+		return fmt.Errorf("failed to create flight data provider: %v", err)
+	}
+
+	aircraftRegistry = nil
+	if appConfig.Oracle.Registry.File != "" {
+		aircraftRegistry, err = oracle.OpenRegistry(appConfig.Oracle.Registry.File)
+		if err != nil {
+			return fmt.Errorf("failed to open aircraft registry: %v", err)
+		}
+	}
+
+	oracleSigner, err = oracle.NewSigner(appConfig.Oracle.Signer)
+	if err != nil {
+		return fmt.Errorf("failed to load oracle signing key: %v", err)
+	}
+	return nil
+}
+
+// companyLookup is the company a report is filed under and, when signing
+// is configured, the oracle attestation of where it came from. Review is set
+// when the report must be flagged for review because oracle sources
+// disagreed.
+type companyLookup struct {
+	Name        string
+	Attestation *oracle.Attestation
+	Review      string
+}
+
+// lookupCompany returns the company a report for aircraftID is filed under:
+// the operator of record of the tail number or ICAO address in the aircraft
+// registry. With the consensus provider its sources must agree on it
+// instead, and an *oracle.ConflictError is returned when they do not. The
+// attestation, if any, is bound to the report assetID and to aircraftID as
+// normalized by the chaincode, and dated when the source answered, which is
+// earlier than now for a cached answer.
+func lookupCompany(ctx context.Context, assetID, aircraftID string) (*companyLookup, error) {
+	aircraftID = normalizeRegistration(aircraftID)
+	var provider, name, review string
+	var response interface{}
+	fetchedAt := time.Now()
+	if consensus, ok := flightProvider.(*oracle.ConsensusProvider); ok {
+		result, err := consensus.Resolve(ctx, aircraftID)
+		if err != nil {
+			return nil, err
+		}
+		provider, name, response = consensus.Name(), result.Flight.AirlineName, result
+		review = describeConflicts(result.Conflicts)
+		if !result.Flight.FetchedAt.IsZero() {
+			fetchedAt = result.Flight.FetchedAt
+		}
+	} else if aircraftRegistry != nil {
+		record, err := aircraftRegistry.LookupAircraft(ctx, aircraftID)
+		if err != nil {
+			return nil, err
+		}
+		provider, name, response = "registry", record.Operator, record
+	} else {
+		return nil, errNoAircraftRegistry
+	}
+
+	lookup := &companyLookup{Name: name, Review: review}
+	if oracleSigner != nil {
+		attestation, err := oracleSigner.Attest(assetID, provider, aircraftID, name, response, fetchedAt)
+		if err != nil {
+			return nil, err
+		}
+		lookup.Attestation = attestation
+	}
+	return lookup, nil
+}
+
+// normalizeRegistration folds a registration the way the chaincode does,
+// so the attested aircraft matches the report's.
+func normalizeRegistration(registration string) string {
+	return strings.ToUpper(strings.TrimSpace(registration))
+}
+
+// describeConflicts returns the review reason for conflicting oracle
+// sources, or "" when they agreed.
+func describeConflicts(conflicts []oracle.Conflict) string {
+	if len(conflicts) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		providers := make([]string, 0, len(conflict.Values))
+		for provider := range conflict.Values {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+		values := make([]string, 0, len(providers))
+		for _, provider := range providers {
+			values = append(values, fmt.Sprintf("%s=%q", provider, conflict.Values[provider]))
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", conflict.Field, strings.Join(values, ", ")))
+	}
+	return "Oracle sources disagree on " + strings.Join(parts, "; ")
+}
+
+// createAssetTransaction returns the chaincode function and arguments that
+// create an asset from the 7 CreateAsset arguments, with the attestation of
+// its company name if there is one. The review reason if it must be flagged,
+// the next due date, both possibly empty, and the check type follow.
+func createAssetTransaction(args []string, attestation *oracle.Attestation, review, dueDate, checkType string) (string, []string, error) {
+	fn := "CreateAsset"
+	if attestation != nil {
+		attestationJSON, err := json.Marshal(attestation)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to marshal attestation: %v", err)
+		}
+		fn, args = "CreateAttestedAsset", append(args, string(attestationJSON))
+	}
+	return fn, append(args, review, dueDate, strings.TrimSpace(checkType)), nil
+}
+
+// runRefreshRegistry implements `api refresh-registry [-source S]`: it
+// replaces the configured registry dataset with a new dump. A running API
+// picks the new file up on its next lookup.
+func runRefreshRegistry(args []string) error {
+	var err error
+	appConfig, err = loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	flags := flag.NewFlagSet("refresh-registry", flag.ContinueOnError)
+	source := flags.String("source", appConfig.Oracle.Registry.Source, "URL or file to download the registry dataset from")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	count, err := oracle.RefreshRegistry(context.Background(), *source, appConfig.Oracle.Registry.File, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Loaded %d aircraft into %s\n", count, appConfig.Oracle.Registry.File)
+	return nil
+}
+
+// writeOracleMetrics reports the flight data cache and rate limiter.
+func writeOracleMetrics(w io.Writer) {
+	if flightProvider == nil {
+		return
+	}
+	stats := oracle.Stats{}
+	if reporter, ok := flightProvider.(oracle.StatsReporter); ok {
+		stats = reporter.Stats()
+	}
+	labels := map[string]string{"provider": flightProvider.Name()}
+
+	writeMetric(w, "oracle_cache_hits_total", "counter",
+		"Flight data lookups answered from the oracle cache.",
+		[]metricSample{{Labels: labels, Value: float64(stats.CacheHits)}})
+	writeMetric(w, "oracle_cache_misses_total", "counter",
+		"Flight data lookups sent to the oracle provider.",
+		[]metricSample{{Labels: labels, Value: float64(stats.CacheMisses)}})
+	writeMetric(w, "oracle_cache_entries", "gauge",
+		"Flights currently held in the oracle cache.",
+		[]metricSample{{Labels: labels, Value: float64(stats.CacheEntries)}})
+	writeMetric(w, "oracle_rate_limited_total", "counter",
+		"Flight data lookups refused by the rate limit or a 429 from the provider.",
+		[]metricSample{{Labels: labels, Value: float64(stats.RateLimited)}})
+	writeMetric(w, "oracle_retries_total", "counter",
+		"Flight data lookups retried after a 429 from the provider.",
+		[]metricSample{{Labels: labels, Value: float64(stats.Retries)}})
+}
+
+// registerOracle registers an oracle public key on the ledger. It must be
+// called with a regulator session. Without a body it registers the key of
+// this API's own oracle signer.
+func registerOracle(c *gin.Context) {
+	var request struct {
+		ID        string `json:"id"`
+		PublicKey string `json:"publicKey"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, "Invalid request payload")
+			return
+		}
+	}
+
+	if request.ID == "" && request.PublicKey == "" && oracleSigner != nil {
+		publicKey, err := oracleSigner.PublicKeyPEM()
+		if err != nil {
+			respondInternalError(c, "Failed to read oracle public key", err)
+			return
+		}
+		request.ID, request.PublicKey = oracleSigner.ID(), publicKey
+	}
+	if request.ID == "" || request.PublicKey == "" {
+		respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, "id and publicKey are required")
+		return
+	}
+
+	response, err := getContract().SubmitWithContext(c.Request.Context(), "RegisterOracle", client.WithArguments(request.ID, request.PublicKey))
+	if err != nil {
+		respondGatewayError(c, "Failed to register oracle", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+// getAssetAttestation returns the oracle attestation stored with an asset.
+func getAssetAttestation(c *gin.Context) {
+	response, err := getContract().EvaluateWithContext(c.Request.Context(), "GetAttestation", client.WithArguments(c.Param("id")))
+	if err != nil {
+		respondGatewayError(c, "Failed to query chaincode", err)
+		return
+	}
+
+	var attestation oracle.Attestation
+	if err := json.Unmarshal(response, &attestation); err != nil {
+		respondInternalError(c, "Failed to unmarshal response", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": attestation})
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aviation-compliance-dapp-oracle/oracle"
+)
+
+func TestLookupCompanyNameFromRegistry(t *testing.T) {
+	registryFile, err := filepath.Abs("../oracle/registry/aircraft.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestConfig(t, testConfigYAML)
+	t.Setenv("ORACLE_PROVIDER", "static")
+	t.Setenv("ORACLE_STATIC_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv("ORACLE_REGISTRY_FILE", registryFile)
+
+	previousConfig, previousProvider := appConfig, flightProvider
+	defer func() {
+		appConfig, flightProvider, aircraftRegistry = previousConfig, previousProvider, nil
+	}()
+	if appConfig, err = loadConfig(); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if err := setupOracle(); err == nil {
+		t.Fatal("expected setupOracle to fail without the static flights file")
+	}
+
+	t.Setenv("ORACLE_PROVIDER", "replay")
+	fixtures, _ := filepath.Abs("../oracle/fixtures")
+	t.Setenv("ORACLE_FIXTURES_DIR", fixtures)
+	if appConfig, err = loadConfig(); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if err := setupOracle(); err != nil {
+		t.Fatalf("setupOracle failed: %v", err)
+	}
+
+	for _, aircraftID := range []string{"PK-LKS", "8a03e2"} {
+		company, err := lookupCompany(context.Background(), "r1", aircraftID)
+		if err != nil || company.Name != "Lion Air" || company.Attestation != nil {
+			t.Errorf("%s: expected the operator of record without attestation, got %+v, %v", aircraftID, company, err)
+		}
+	}
+	if _, err := lookupCompany(context.Background(), "r1", "GA404"); !errors.Is(err, oracle.ErrAircraftNotFound) {
+		t.Errorf("expected a flight number to be rejected by the registry, got %v", err)
+	}
+
+	// With a signing key the operator comes with a verifiable attestation
+	keyFile := filepath.Join(t.TempDir(), "oracle-key.pem")
+	publicKey, err := oracle.GenerateSigningKey(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ORACLE_ID", "api-oracle")
+	t.Setenv("ORACLE_SIGNING_KEY", keyFile)
+	if appConfig, err = loadConfig(); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if err := setupOracle(); err != nil {
+		t.Fatalf("setupOracle failed: %v", err)
+	}
+	defer func() { oracleSigner = nil }()
+
+	company, err := lookupCompany(context.Background(), "r1", " pk-lks")
+	if err != nil || company.Attestation == nil {
+		t.Fatalf("expected an attestation, got %+v, %v", company, err)
+	}
+	attestation := company.Attestation
+	if attestation.AssetID != "r1" || attestation.OracleID != "api-oracle" || attestation.Provider != "registry" || attestation.Request != "PK-LKS" || attestation.Result != "Lion Air" {
+		t.Errorf("unexpected attestation %+v", attestation)
+	}
+	if err := attestation.Verify(publicKey); err != nil {
+		t.Errorf("expected the attestation to verify: %v", err)
+	}
+
+	args := []string{"r1", company.Name, "PK-LKS", "2024-12-01", "Inspector Y", "Check", "true"}
+	fn, withAttestation, err := createAssetTransaction(args[:7:7], attestation, "", "", "A-check")
+	if err != nil || fn != "CreateAttestedAsset" || len(withAttestation) != 11 || withAttestation[10] != "A-check" {
+		t.Errorf("expected CreateAttestedAsset with 11 arguments, got %s %v, %v", fn, withAttestation, err)
+	}
+	if fn, _, _ := createAssetTransaction(args[:7:7], nil, "", "", "A-check"); fn != "CreateAsset" {
+		t.Errorf("expected CreateAsset without an attestation, got %s", fn)
+	}
+	if fn, flagged, _ := createAssetTransaction(args[:7:7], attestation, "check", "", "A-check"); fn != "CreateAttestedAsset" || len(flagged) != 11 || flagged[8] != "check" {
+		t.Errorf("expected the review reason as 9th argument, got %s %v", fn, flagged)
+	}
+	if fn, due, _ := createAssetTransaction(args[:7:7], nil, "", "2025-06-01", " A-check "); fn != "CreateAsset" || len(due) != 10 || due[7] != "" || due[8] != "2025-06-01" || due[9] != "A-check" {
+		t.Errorf("expected an empty review before the due date and the check type, got %s %v", fn, due)
+	}
+}
+
+func TestLookupCompanyConsensus(t *testing.T) {
+	registryFile, err := filepath.Abs("../oracle/registry/aircraft.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	staticFile := filepath.Join(t.TempDir(), "flights.json")
+	flights := `{"PK-GFA": {"airline_name": "PT Garuda Indonesia (Persero) Tbk"}, "PK-LKS": {"airline_name": "Wings Air"}}`
+	if err := os.WriteFile(staticFile, []byte(flights), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	previousConfig, previousProvider := appConfig, flightProvider
+	defer func() {
+		appConfig, flightProvider, aircraftRegistry = previousConfig, previousProvider, nil
+	}()
+	setup := func(onConflict string) {
+		writeTestConfig(t, testConfigYAML+fmt.Sprintf(`
+oracle:
+  provider: consensus
+  static:
+    file: %s
+  registry:
+    file: %s
+  consensus:
+    sources: [registry, static]
+    onConflict: %s
+`, staticFile, registryFile, onConflict))
+		if appConfig, err = loadConfig(); err != nil {
+			t.Fatalf("loadConfig failed: %v", err)
+		}
+		if err := setupOracle(); err != nil {
+			t.Fatalf("setupOracle failed: %v", err)
+		}
+	}
+
+	setup("refuse")
+	company, err := lookupCompany(context.Background(), "r1", "PK-GFA")
+	if err != nil || company.Name != "Garuda Indonesia" || company.Review != "" {
+		t.Errorf("expected the sources to agree on Garuda Indonesia, got %+v, %v", company, err)
+	}
+	var conflictErr *oracle.ConflictError
+	if _, err := lookupCompany(context.Background(), "r1", "PK-LKS"); !errors.As(err, &conflictErr) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	if values := conflictErr.Result.Conflicts[0].Values; values["registry"] != "Lion Air" || values["static"] != "Wings Air" {
+		t.Errorf("expected the values of each source, got %+v", conflictErr.Result.Conflicts)
+	}
+
+	// Flagging needs a quorum the disagreeing sources can still reach
+	setup("flag\n    quorum: 1")
+	company, err = lookupCompany(context.Background(), "r1", "PK-LKS")
+	if err != nil || company.Name != "Lion Air" || !strings.Contains(company.Review, `static="Wings Air"`) {
+		t.Errorf("expected the report to be flagged for review, got %+v, %v", company, err)
+	}
+}
+
+func TestLookupCompanyNeedsAircraftSources(t *testing.T) {
+	previousConfig, previousProvider := appConfig, flightProvider
+	defer func() {
+		appConfig, flightProvider, aircraftRegistry = previousConfig, previousProvider, nil
+	}()
+
+	writeTestConfig(t, testConfigYAML+`
+oracle:
+  provider: consensus
+  consensus:
+    sources: [registry, aviationstack]
+`)
+	var err error
+	if appConfig, err = loadConfig(); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if err := setupOracle(); err == nil || !strings.Contains(err.Error(), "aviationstack looks up flights") {
+		t.Errorf("expected a flight provider to be refused as consensus source, got %v", err)
+	}
+
+	writeTestConfig(t, testConfigYAML+`
+oracle:
+  provider: aviationstack
+`)
+	if appConfig, err = loadConfig(); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if err := setupOracle(); err != nil {
+		t.Fatalf("setupOracle failed: %v", err)
+	}
+	if _, err := lookupCompany(context.Background(), "r1", "PK-GFA"); !errors.Is(err, errNoAircraftRegistry) {
+		t.Errorf("expected errNoAircraftRegistry without a registry, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+const (
+	// healthProbeKey is looked up by the chaincode check. Whether the asset
+	// exists does not matter, only that the chaincode answers.
+	healthProbeKey     = "healthcheck"
+	healthCheckTimeout = 3 * time.Second
+)
+
+type sessionHealth struct {
+	Healthy bool   `json:"healthy"`
+	Label   string `json:"label,omitempty"`
+	MspID   string `json:"mspId,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type peerHealth struct {
+	Healthy bool `json:"healthy"`
+	*PeerStatus
+	Error string `json:"error,omitempty"`
+}
+
+type chaincodeHealth struct {
+	Healthy   bool   `json:"healthy"`
+	Chaincode string `json:"chaincode,omitempty"`
+	LatencyMs int64  `json:"latencyMs,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type healthReport struct {
+	Status    string          `json:"status"`
+	Session   sessionHealth   `json:"session"`
+	Peer      peerHealth      `json:"peer"`
+	Chaincode chaincodeHealth `json:"chaincode"`
+}
+
+func (r healthReport) ready() bool {
+	return r.Session.Healthy && r.Peer.Healthy && r.Chaincode.Healthy
+}
+
+// checkHealth reports the wallet session, the peer connection and whether
+// the chaincode answers a cheap evaluate.
+func checkHealth(ctx context.Context) healthReport {
+	var report healthReport
+
+	session := getCurrentSession()
+	switch {
+	case session == nil:
+		report.Session.Error = "no wallet session, sign in first"
+	case session.Revoked():
+		report.Session = sessionHealth{Label: session.Label(), MspID: session.MspID(), Error: "identity has been removed from the wallet"}
+	default:
+		report.Session = sessionHealth{Healthy: true, Label: session.Label(), MspID: session.MspID()}
+	}
+
+	gateway, connection := currentGateway()
+	if connection == nil {
+		report.Peer.Error = "not connected"
+	} else {
+		status := connection.Status()
+		report.Peer = peerHealth{Healthy: connection.Ready(), PeerStatus: &status}
+	}
+
+	if gateway == nil || !report.Peer.Healthy {
+		report.Chaincode.Error = "peer is not reachable"
+		return report
+	}
+
+	channel, chaincode := channelAndChaincode()
+	contract := gateway.GetNetwork(channel).GetContract(chaincode)
+	report.Chaincode.Chaincode = contract.ChaincodeName()
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := contract.EvaluateWithContext(ctx, "AssetExists", client.WithArguments(healthProbeKey))
+	report.Chaincode.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		report.Chaincode.Error = fmt.Sprintf("Failed to query chaincode: %v", err)
+		return report
+	}
+	report.Chaincode.Healthy = true
+	return report
+}
+
+// getHealthz is the liveness probe: the process answers, so it is alive.
+// The report is included to help diagnose a failing readiness probe.
+func getHealthz(c *gin.Context) {
+	report := checkHealth(c.Request.Context())
+	report.Status = "ok"
+	c.JSON(http.StatusOK, report)
+}
+
+// getReadyz is the readiness probe. It fails until a wallet session is
+// signed in and the peer and chaincode answer through it.
+func getReadyz(c *gin.Context) {
+	report := checkHealth(c.Request.Context())
+	if !report.ready() {
+		report.Status = "not ready"
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	report.Status = "ready"
+	c.JSON(http.StatusOK, report)
+}
+
+// writeConnectionMetrics exports the peer connection state.
+func writeConnectionMetrics(w io.Writer) {
+	_, connection := currentGateway()
+	if connection == nil {
+		return
+	}
+	status := connection.Status()
+	labels := map[string]string{"peer": status.Peer, "address": status.Address}
+
+	ready := 0.0
+	if connection.Ready() {
+		ready = 1
+	}
+	writeMetric(w, "gateway_peer_ready", "gauge",
+		"Whether the gateway peer connection is ready (1) or not (0).",
+		[]metricSample{{Labels: labels, Value: ready}})
+	writeMetric(w, "gateway_peer_reconnects_total", "counter",
+		"Reconnects of the gateway peer connection since sign-in.",
+		[]metricSample{{Labels: labels, Value: float64(status.Reconnects)}})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+func TestPeerConnectionReconnectsToNextPeer(t *testing.T) {
+	first, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	firstServer := grpc.NewServer()
+	go firstServer.Serve(first)
+
+	second, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	secondServer := grpc.NewServer()
+	go secondServer.Serve(second)
+	defer secondServer.Stop()
+
+	previous := appConfig
+	appConfig = &Config{
+		ConnectTimeout:      5 * time.Second,
+		ReconnectBackoff:    10 * time.Millisecond,
+		ReconnectMaxBackoff: 50 * time.Millisecond,
+	}
+	defer func() { appConfig = previous }()
+
+	org := &OrgConfig{MSPID: "Org1MSP", endpoints: []PeerEndpoint{
+		{Name: "peer0", Address: first.Addr().String(), Insecure: true},
+		{Name: "peer1", Address: second.Addr().String(), Insecure: true},
+	}}
+
+	connection, err := newPeerConnection(org)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer connection.Close()
+	if status := connection.Status(); status.Peer != "peer0" || !connection.Ready() {
+		t.Fatalf("expected a ready connection to peer0, got %+v", status)
+	}
+
+	firstServer.Stop()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		status := connection.Status()
+		if status.Peer == "peer1" && connection.Ready() {
+			if status.Reconnects != 1 {
+				t.Errorf("expected 1 reconnect, got %d", status.Reconnects)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected reconnect to peer1, got %+v", status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestReadyzWithoutSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/healthz", getHealthz)
+	router.GET("/readyz", getReadyz)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a session, got %d", recorder.Code)
+	}
+
+	var report healthReport
+	if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if report.Session.Healthy || report.Peer.Healthy || report.Chaincode.Healthy {
+		t.Errorf("expected every check to fail, got %+v", report)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected liveness to succeed, got %d", recorder.Code)
+	}
+}
+
+func TestConnectGatewayKeepsConnectionOnFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(listener)
+	defer server.Stop()
+
+	previous := appConfig
+	appConfig = &Config{ConnectTimeout: 5 * time.Second}
+	defer func() { appConfig = previous }()
+	defer closeGateway()
+	defer setCurrentSession(nil)
+
+	session, err := newGatewaySession("user_identity", newTestIdentity(t, "Org1MSP", testNow.AddDate(1, 0, 0)))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	org := &OrgConfig{MSPID: "Org1MSP", endpoints: []PeerEndpoint{
+		{Name: "peer0", Address: listener.Addr().String(), Insecure: true},
+	}}
+	if err := connectGateway(session, org); err != nil {
+		t.Fatalf("connectGateway failed: %v", err)
+	}
+	connected, connection := currentGateway()
+
+	// Probes read the gateway while a sign-in replaces it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			checkHealth(context.Background())
+			writeConnectionMetrics(io.Discard)
+		}
+	}()
+	unreachable := &OrgConfig{MSPID: "Org1MSP", endpoints: []PeerEndpoint{
+		{Name: "peer0", Address: "127.0.0.1:1", Insecure: true},
+	}}
+	if err := connectGateway(session, unreachable); err == nil {
+		t.Error("expected connectGateway to fail without a reachable peer")
+	}
+	<-done
+
+	if current, currentConnection := currentGateway(); current != connected || currentConnection != connection || !connection.Ready() {
+		t.Errorf("expected the working gateway to be kept after a failed sign-in")
+	}
+	closeGateway()
+	if current, currentConnection := currentGateway(); current != nil || currentConnection != nil {
+		t.Errorf("expected no gateway after closing it")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"aviation-compliance-dapp-wallet/wallet"
+)
+
+// runImport implements `api import [flags] FILE`: it signs in with an
+// identity from the wallet, or from certificate and key files, and bulk
+// creates the reports in FILE, printing the per-row report as JSON.
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	label := flags.String("identity", "user_identity", "wallet label of the identity to submit with")
+	mspID := flags.String("msp", "", "MSP ID, when signing in with -cert and -key instead of the wallet")
+	certPath := flags.String("cert", "", "certificate file of the identity")
+	keyPath := flags.String("key", "", "private key file of the identity")
+	format := flags.String("format", "", "csv or jsonl (default: from the file extension)")
+	parallelism := flags.Int("parallelism", 0, "transactions in flight at once (default: bulkParallelism from the config)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: api import [flags] FILE")
+	}
+	path := flags.Arg(0)
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	var err error
+	appConfig, err = loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+	if *parallelism <= 0 {
+		*parallelism = appConfig.BulkParallelism
+	}
+	if err := setupOracle(); err != nil {
+		return err
+	}
+
+	var identity *wallet.X509Identity
+	if *certPath != "" || *keyPath != "" {
+		identity, err = wallet.LoadIdentityFromFiles(*mspID, *certPath, *keyPath)
+		if err != nil {
+			return fmt.Errorf("failed to load identity: %v", err)
+		}
+	} else {
+		store, err := wallet.NewWalletStoreFromEnv()
+		if err != nil {
+			return fmt.Errorf("failed to open wallet store: %v", err)
+		}
+		stored, err := wallet.OpenWallet(store).Get(*label)
+		if err != nil {
+			return fmt.Errorf("failed to read identity %s from wallet: %v", *label, err)
+		}
+		identity = &stored
+	}
+
+	org, err := appConfig.Organization(identity.MSP)
+	if err != nil {
+		return err
+	}
+	session, err := newGatewaySession(*label, *identity)
+	if err != nil {
+		return fmt.Errorf("failed to get signing implementation: %v", err)
+	}
+	if err := connectGateway(session, org); err != nil {
+		return err
+	}
+	defer closeGateway()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	rows, err := parseBulkRows(file, *format)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	report := newBulkImporter(getContract(), *parallelism).Import(ctx, rows)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d rows: %d created, %d duplicate, %d invalid, %d failed\n",
+		report.Total, report.Created, report.Duplicate, report.Invalid, report.Failed)
+
+	if report.Invalid > 0 || report.Failed > 0 {
+		return fmt.Errorf("%d rows were not imported", report.Invalid+report.Failed)
+	}
+	return nil
+}
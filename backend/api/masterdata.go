@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Airline, aircraft and inspector master data. Assets are filed by a
+// licensed inspector under a registered airline and aircraft, which the
+// chaincode keys by license number, ICAO code and registration.
+
+// errUnknownAirline is returned for a company that is not a registered
+// airline.
+var errUnknownAirline = errors.New("not a registered airline")
+
+// airlineRef is the part of an airline record reports reference it by.
+type airlineRef struct {
+	ICAO string `json:"icao"`
+	Name string `json:"name"`
+}
+
+// airlineICAO returns the ICAO code the chaincode references an airline by,
+// given the code or the registered name, as the oracle reports it.
+func airlineICAO(ctx context.Context, contract *client.Contract, company string) (string, error) {
+	response, err := contract.EvaluateWithContext(ctx, "GetAllAirlines")
+	if err != nil {
+		return "", err
+	}
+	var airlines []airlineRef
+	if err := json.Unmarshal(response, &airlines); err != nil {
+		return "", fmt.Errorf("failed to parse airlines: %v", err)
+	}
+	return matchAirline(airlines, company)
+}
+
+// matchAirline returns the ICAO code of the airline with the given code or
+// name, ignoring case and spacing.
+func matchAirline(airlines []airlineRef, company string) (string, error) {
+	normalized := strings.Join(strings.Fields(strings.ToUpper(company)), " ")
+	for _, airline := range airlines {
+		if airline.ICAO == normalized {
+			return airline.ICAO, nil
+		}
+	}
+	for _, airline := range airlines {
+		if strings.Join(strings.Fields(strings.ToUpper(airline.Name)), " ") == normalized {
+			return airline.ICAO, nil
+		}
+	}
+	return "", fmt.Errorf("%q is %w", company, errUnknownAirline)
+}
+
+// submitMasterData returns a handler submitting the JSON body to the
+// chaincode function fn. On routes with an :id the ID is taken from the path
+// and stored under keyField.
+func submitMasterData(fn, keyField, message string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var record map[string]interface{}
+		if err := c.ShouldBindJSON(&record); err != nil {
+			respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, "Invalid request payload")
+			return
+		}
+		if id := c.Param("id"); id != "" {
+			if existing, ok := record[keyField].(string); ok && existing != "" && !strings.EqualFold(existing, id) {
+				respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, fmt.Sprintf("%s %q does not match the path", keyField, existing))
+				return
+			}
+			record[keyField] = id
+		}
+		recordJSON, err := json.Marshal(record)
+		if err != nil {
+			respondInternalError(c, "Failed to marshal request", err)
+			return
+		}
+
+		response, err := getContract().SubmitWithContext(c.Request.Context(), fn, client.WithArguments(string(recordJSON)))
+		if err != nil {
+			respondGatewayError(c, message, err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", response)
+	}
+}
+
+// evaluateMasterData returns a handler evaluating the chaincode function fn
+// with the :id of the route, if any.
+func evaluateMasterData(fn string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var args []string
+		if id := c.Param("id"); id != "" {
+			args = append(args, id)
+		}
+		response, err := getContract().EvaluateWithContext(c.Request.Context(), fn, client.WithArguments(args...))
+		if err != nil {
+			respondGatewayError(c, "Failed to query chaincode", err)
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json", response)
+	}
+}
+
+// deleteMasterData returns a handler submitting the chaincode function fn
+// with the :id of the route.
+func deleteMasterData(fn, message string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response, err := getContract().SubmitWithContext(c.Request.Context(), fn, client.WithArguments(c.Param("id")))
+		if err != nil {
+			respondGatewayError(c, message, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": string(response)})
+	}
+}
+
+func registerMasterDataRoutes(router gin.IRouter) {
+	router.POST("/airlines", submitMasterData("CreateAirline", "icao", "Failed to create airline"))
+	router.GET("/airlines", evaluateMasterData("GetAllAirlines"))
+	router.GET("/airlines/:id", evaluateMasterData("ReadAirline"))
+	router.PUT("/airlines/:id", submitMasterData("UpdateAirline", "icao", "Failed to update airline"))
+	router.DELETE("/airlines/:id", deleteMasterData("DeleteAirline", "Failed to delete airline"))
+	router.GET("/airlines/:id/history", evaluateMasterData("GetAirlineHistory"))
+
+	router.POST("/aircraft", submitMasterData("CreateAircraft", "registration", "Failed to create aircraft"))
+	router.GET("/aircraft", evaluateMasterData("GetAllAircraft"))
+	router.GET("/aircraft/:id", evaluateMasterData("ReadAircraft"))
+	router.PUT("/aircraft/:id", submitMasterData("UpdateAircraft", "registration", "Failed to update aircraft"))
+	router.DELETE("/aircraft/:id", deleteMasterData("DeleteAircraft", "Failed to delete aircraft"))
+	router.GET("/aircraft/:id/history", evaluateMasterData("GetAircraftHistory"))
+
+	router.POST("/inspectors", submitMasterData("RegisterInspector", "licenseNumber", "Failed to register inspector"))
+	router.GET("/inspectors", evaluateMasterData("GetAllInspectors"))
+	router.GET("/inspectors/:id", evaluateMasterData("ReadInspector"))
+	router.PUT("/inspectors/:id", submitMasterData("UpdateInspector", "licenseNumber", "Failed to update inspector"))
+	router.GET("/inspectors/:id/history", evaluateMasterData("GetInspectorHistory"))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSubmitMasterDataRejectsMismatchedID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	registerMasterDataRoutes(router)
+
+	for _, request := range []struct{ path, body string }{
+		{"/airlines/GIA", `{"icao": "LNI", "name": "Lion Air"}`},
+		{"/aircraft/PK-GFA", `{"registration": "PK-LKS"}`},
+		{"/aircraft/PK-GFA", `not json`},
+		{"/inspectors/AMEL-1", `{"licenseNumber": "AMEL-2"}`},
+	} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, request.path, strings.NewReader(request.body)))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s %s: expected 400, got %d", request.path, request.body, recorder.Code)
+		}
+	}
+}
+
+func TestMatchAirline(t *testing.T) {
+	airlines := []airlineRef{{ICAO: "GIA", Name: "Garuda Indonesia"}, {ICAO: "LNI", Name: "Lion Air"}}
+
+	for _, company := range []string{"LNI", "lni", "Lion Air", "  LION   air "} {
+		if icao, err := matchAirline(airlines, company); err != nil || icao != "LNI" {
+			t.Errorf("%q: expected LNI, got %q, %v", company, icao, err)
+		}
+	}
+	if _, err := matchAirline(airlines, "Wings Air"); !errors.Is(err, errUnknownAirline) {
+		t.Errorf("expected errUnknownAirline, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricSample is a single value of a metric with its label set.
+type metricSample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// metricsCollector writes one or more metrics in the Prometheus text format.
+type metricsCollector func(w io.Writer)
+
+var (
+	metricsMu         sync.Mutex
+	metricsCollectors []metricsCollector
+)
+
+func registerMetrics(collector metricsCollector) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metricsCollectors = append(metricsCollectors, collector)
+}
+
+func writeMetric(w io.Writer, name, kind, help string, samples []metricSample) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+	for _, sample := range samples {
+		fmt.Fprintf(w, "%s%s %g\n", name, formatLabels(sample.Labels), sample.Value)
+	}
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[key])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, key, value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func getMetrics(c *gin.Context) {
+	metricsMu.Lock()
+	collectors := append([]metricsCollector(nil), metricsCollectors...)
+	metricsMu.Unlock()
+
+	c.Header("Content-Type", "text/plain; version=0.0.4")
+	c.Status(http.StatusOK)
+	for _, collector := range collectors {
+		collector(c.Writer)
+	}
+}
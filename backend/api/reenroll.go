@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"aviation-compliance-dapp-wallet/wallet"
+)
+
+const reenrollPath = "/api/v1/reenroll"
+
+type reenrollResponse struct {
+	Success bool `json:"success"`
+	Result  struct {
+		Cert string `json:"Cert"`
+	} `json:"result"`
+	Errors []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// reenrollIdentity asks the Fabric CA for a new certificate for the same
+// subject, authenticating with the identity's current certificate, and
+// returns the identity with a freshly generated key.
+func reenrollIdentity(identity wallet.X509Identity, ca *CAConfig) (wallet.X509Identity, error) {
+	cert, err := parseCertificate(identity.Cert)
+	if err != nil {
+		return wallet.X509Identity{}, err
+	}
+
+	sign, err := wallet.NewPrivateKeySigner(identity.Key)
+	if err != nil {
+		return wallet.X509Identity{}, err
+	}
+
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return wallet.X509Identity{}, fmt.Errorf("failed to generate private key: %v", err)
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: cert.Subject.CommonName},
+	}, newKey)
+	if err != nil {
+		return wallet.X509Identity{}, fmt.Errorf("failed to create certificate request: %v", err)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"certificate_request": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})),
+		"caname":              ca.CAName,
+	})
+	if err != nil {
+		return wallet.X509Identity{}, err
+	}
+
+	// Fabric CA token: base64(cert) "." base64(signature over the request)
+	b64cert := base64.StdEncoding.EncodeToString([]byte(identity.Cert))
+	payload := http.MethodPost + "." +
+		base64.StdEncoding.EncodeToString([]byte(reenrollPath)) + "." +
+		base64.StdEncoding.EncodeToString(body) + "." +
+		b64cert
+	digest := sha256.Sum256([]byte(payload))
+	signature, err := sign(digest[:])
+	if err != nil {
+		return wallet.X509Identity{}, err
+	}
+
+	httpClient, err := caHTTPClient(ca)
+	if err != nil {
+		return wallet.X509Identity{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, ca.URL+reenrollPath, bytes.NewReader(body))
+	if err != nil {
+		return wallet.X509Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", b64cert+"."+base64.StdEncoding.EncodeToString(signature))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return wallet.X509Identity{}, fmt.Errorf("failed to reach CA: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return wallet.X509Identity{}, fmt.Errorf("failed to read CA response: %v", err)
+	}
+
+	var result reenrollResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return wallet.X509Identity{}, fmt.Errorf("failed to parse CA response (status %d): %v", resp.StatusCode, err)
+	}
+	if !result.Success {
+		if len(result.Errors) > 0 {
+			return wallet.X509Identity{}, fmt.Errorf("CA rejected re-enrollment: %s (code %d)", result.Errors[0].Message, result.Errors[0].Code)
+		}
+		return wallet.X509Identity{}, fmt.Errorf("CA rejected re-enrollment with status code %d", resp.StatusCode)
+	}
+
+	newCert, err := base64.StdEncoding.DecodeString(result.Result.Cert)
+	if err != nil {
+		return wallet.X509Identity{}, fmt.Errorf("failed to decode issued certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(newKey)
+	if err != nil {
+		return wallet.X509Identity{}, fmt.Errorf("failed to encode private key: %v", err)
+	}
+
+	return wallet.X509Identity{
+		MSP:  identity.MSP,
+		Cert: string(newCert),
+		Key:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+	}, nil
+}
+
+func caHTTPClient(ca *CAConfig) (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+	certificatePEM := ca.TLSCertPEM
+	if len(certificatePEM) == 0 && ca.TLSCertPath != "" {
+		var err error
+		certificatePEM, err = os.ReadFile(ca.TLSCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA TLS certificate: %w", err)
+		}
+	}
+	if len(certificatePEM) > 0 {
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(certificatePEM) {
+			return nil, fmt.Errorf("failed to add CA TLS certificate to pool")
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}, nil
+}
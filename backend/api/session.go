@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"aviation-compliance-dapp-wallet/wallet"
+)
+
+// gatewaySession is the identity handed to the Fabric gateway. The gateway
+// reads the credentials and calls the signer on every proposal, so swapping
+// the identity here takes effect on the live connection without reconnecting.
+type gatewaySession struct {
+	mu       sync.RWMutex
+	label    string
+	identity wallet.X509Identity
+	sign     wallet.Sign
+	revoked  bool
+}
+
+func newGatewaySession(label string, identity wallet.X509Identity) (*gatewaySession, error) {
+	session := &gatewaySession{label: label}
+	if err := session.Swap(identity); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *gatewaySession) Label() string {
+	return s.label
+}
+
+func (s *gatewaySession) MspID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.identity.MSP
+}
+
+func (s *gatewaySession) Credentials() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return []byte(s.identity.Cert)
+}
+
+func (s *gatewaySession) Sign(digest []byte) ([]byte, error) {
+	s.mu.RLock()
+	sign := s.sign
+	s.mu.RUnlock()
+	return sign(digest)
+}
+
+// Revoke makes every further signature fail, for when the identity has
+// been removed from the wallet.
+func (s *gatewaySession) Revoke() {
+	s.mu.Lock()
+	s.sign = func(digest []byte) ([]byte, error) {
+		return nil, errors.New("identity has been removed from the wallet, sign in again")
+	}
+	s.revoked = true
+	s.mu.Unlock()
+}
+
+func (s *gatewaySession) Revoked() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.revoked
+}
+
+// Swap replaces the certificate and signer used by the session.
+func (s *gatewaySession) Swap(identity wallet.X509Identity) error {
+	sign, err := identity.Signer()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.identity = identity
+	s.sign = sign
+	s.revoked = false
+	s.mu.Unlock()
+	return nil
+}
+
+var (
+	sessionMu      sync.Mutex
+	currentSession *gatewaySession
+)
+
+func setCurrentSession(session *gatewaySession) {
+	sessionMu.Lock()
+	currentSession = session
+	sessionMu.Unlock()
+}
+
+func getCurrentSession() *gatewaySession {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return currentSession
+}
+
+// watchSessionIdentity keeps the live gateway session in step with the
+// wallet: a rotated identity, e.g. after re-enrollment, is swapped in and a
+// removed one revokes the session.
+func watchSessionIdentity(store wallet.WalletStore, stop <-chan struct{}) {
+	watchable, ok := store.(wallet.WatchableWalletStore)
+	if !ok {
+		return
+	}
+	events, cancel := watchable.Watch()
+	defer cancel()
+
+	for {
+		select {
+		case event := <-events:
+			handleWalletEvent(store, event)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func handleWalletEvent(store wallet.WalletStore, event wallet.WalletEvent) {
+	session := getCurrentSession()
+	if session == nil || session.Label() != event.Label {
+		return
+	}
+
+	switch event.Type {
+	case wallet.IdentityRotated:
+		data, err := store.Get(event.Label)
+		if err != nil {
+			log.Printf("Failed to read rotated identity %s: %v", event.Label, err)
+			return
+		}
+		identity, err := wallet.X509IdentityFromJSON(data)
+		if err != nil {
+			log.Printf("Failed to decode rotated identity %s: %v", event.Label, err)
+			return
+		}
+		if identity.MSP != session.MspID() {
+			return
+		}
+		if err := session.Swap(*identity); err != nil {
+			log.Printf("Failed to swap signer of live session: %v", err)
+			return
+		}
+		log.Printf("Live gateway session now signs with the rotated identity %s", event.Label)
+	case wallet.IdentityRemoved:
+		session.Revoke()
+		log.Printf("Identity %s was removed from the wallet, live session revoked", event.Label)
+	}
+}
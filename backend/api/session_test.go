@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"aviation-compliance-dapp-wallet/wallet"
+)
+
+func TestRemovedIdentityRevokesSession(t *testing.T) {
+	store := &wallet.FileWalletStore{}
+	identity := newTestIdentity(t, "Org1MSP", testNow.AddDate(1, 0, 0))
+	putIdentity(t, store, "user_identity", identity)
+
+	live, err := newGatewaySession("user_identity", identity)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	setCurrentSession(live)
+	defer setCurrentSession(nil)
+
+	if _, err := live.Sign(make([]byte, 32)); err != nil {
+		t.Fatalf("expected session to sign before removal: %v", err)
+	}
+	handleWalletEvent(store, wallet.WalletEvent{Type: wallet.IdentityRemoved, Label: "user_identity"})
+	if _, err := live.Sign(make([]byte, 32)); err == nil {
+		t.Fatalf("expected signing to fail once the identity is removed")
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Transaction states reported by GET /transactions/:txid.
+const (
+	TransactionPending = "pending"
+	TransactionValid   = "valid"
+	TransactionInvalid = "invalid"
+	// TransactionUnknown means the commit status could not be read, e.g.
+	// because it timed out; the ledger is asked again on the next lookup.
+	TransactionUnknown = "unknown"
+)
+
+// TransactionRecord is the commit state of a submitted transaction.
+type TransactionRecord struct {
+	TransactionID  string     `json:"transactionId"`
+	Function       string     `json:"function,omitempty"`
+	Status         string     `json:"status"`
+	ValidationCode string     `json:"validationCode,omitempty"`
+	BlockNumber    *uint64    `json:"blockNumber,omitempty"`
+	SubmittedAt    *time.Time `json:"submittedAt,omitempty"`
+	CommittedAt    *time.Time `json:"committedAt,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// pendingCommit is the part of client.Commit the tracker needs.
+type pendingCommit interface {
+	TransactionID() string
+	Status(opts ...grpc.CallOption) (*client.Status, error)
+}
+
+// transactionTracker follows transactions submitted asynchronously until
+// they commit and remembers the outcome for a while.
+type transactionTracker struct {
+	retention time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	records map[string]*TransactionRecord
+}
+
+var transactions = newTransactionTracker(24 * time.Hour)
+
+func newTransactionTracker(retention time.Duration) *transactionTracker {
+	return &transactionTracker{
+		retention: retention,
+		now:       time.Now,
+		records:   make(map[string]*TransactionRecord),
+	}
+}
+
+// Track records the transaction as pending and waits for its commit status
+// in the background.
+func (t *transactionTracker) Track(function string, commit pendingCommit) TransactionRecord {
+	submittedAt := t.now()
+	record := &TransactionRecord{
+		TransactionID: commit.TransactionID(),
+		Function:      function,
+		Status:        TransactionPending,
+		SubmittedAt:   &submittedAt,
+	}
+
+	t.mu.Lock()
+	t.purge()
+	t.records[record.TransactionID] = record
+	result := *record
+	t.mu.Unlock()
+
+	go t.wait(commit)
+	return result
+}
+
+func (t *transactionTracker) wait(commit pendingCommit) {
+	status, err := commit.Status()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.records[commit.TransactionID()]
+	if !ok {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get commit status of %s: %v", record.TransactionID, err)
+		record.Status = TransactionUnknown
+		record.Error = err.Error()
+		return
+	}
+	applyCommitStatus(record, status.Code, status.BlockNumber)
+	committedAt := t.now()
+	record.CommittedAt = &committedAt
+}
+
+func applyCommitStatus(record *TransactionRecord, code peer.TxValidationCode, blockNumber uint64) {
+	record.Status = TransactionInvalid
+	if code == peer.TxValidationCode_VALID {
+		record.Status = TransactionValid
+	}
+	record.ValidationCode = code.String()
+	record.BlockNumber = &blockNumber
+	record.Error = ""
+}
+
+// Get returns the tracked state of a transaction.
+func (t *transactionTracker) Get(txID string) (TransactionRecord, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, ok := t.records[txID]
+	if !ok {
+		return TransactionRecord{}, false
+	}
+	return *record, true
+}
+
+// purge forgets finished transactions older than the retention period. The
+// caller holds t.mu.
+func (t *transactionTracker) purge() {
+	cutoff := t.now().Add(-t.retention)
+	for txID, record := range t.records {
+		if record.Status != TransactionPending && record.SubmittedAt.Before(cutoff) {
+			delete(t.records, txID)
+		}
+	}
+}
+
+// wantsAsync reports whether the caller asked for ?async=true.
+func wantsAsync(c *gin.Context) bool {
+	async, _ := strconv.ParseBool(c.Query("async"))
+	return async
+}
+
+// submitAsync endorses and orders a transaction, then answers 202 with its
+// ID while the commit status is tracked in the background.
+func submitAsync(c *gin.Context, contract *client.Contract, message, function string, options ...client.ProposalOption) {
+	_, commit, err := contract.SubmitAsyncWithContext(c.Request.Context(), function, options...)
+	if err != nil {
+		respondGatewayError(c, message, err)
+		return
+	}
+
+	record := transactions.Track(function, commit)
+	c.Header("Location", "/transactions/"+record.TransactionID)
+	c.JSON(http.StatusAccepted, record)
+}
+
+// lookupTransaction asks the ledger, through the query system chaincode,
+// for the validation code and block of a committed transaction.
+func lookupTransaction(ctx context.Context, txID string) (TransactionRecord, error) {
+	network := getNetwork()
+	qscc := network.GetContract("qscc")
+
+	processedBytes, err := qscc.EvaluateWithContext(ctx, "GetTransactionByID", client.WithArguments(network.Name(), txID))
+	if err != nil {
+		return TransactionRecord{}, err
+	}
+	var processed peer.ProcessedTransaction
+	if err := proto.Unmarshal(processedBytes, &processed); err != nil {
+		return TransactionRecord{}, fmt.Errorf("failed to parse transaction: %v", err)
+	}
+
+	blockBytes, err := qscc.EvaluateWithContext(ctx, "GetBlockByTxID", client.WithArguments(network.Name(), txID))
+	if err != nil {
+		return TransactionRecord{}, err
+	}
+	var block common.Block
+	if err := proto.Unmarshal(blockBytes, &block); err != nil {
+		return TransactionRecord{}, fmt.Errorf("failed to parse block: %v", err)
+	}
+
+	record := TransactionRecord{TransactionID: txID}
+	applyCommitStatus(&record, peer.TxValidationCode(processed.GetValidationCode()), block.GetHeader().GetNumber())
+	return record, nil
+}
+
+func getTransaction(c *gin.Context) {
+	txID := c.Param("txid")
+
+	record, ok := transactions.Get(txID)
+	if ok && (record.Status == TransactionPending || record.Status == TransactionValid || record.Status == TransactionInvalid) {
+		c.JSON(http.StatusOK, record)
+		return
+	}
+
+	committed, err := lookupTransaction(c.Request.Context(), txID)
+	if err != nil {
+		respondGatewayError(c, "Failed to look up transaction", err)
+		return
+	}
+	committed.Function, committed.SubmittedAt = record.Function, record.SubmittedAt
+	c.JSON(http.StatusOK, committed)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc"
+)
+
+type fakeCommit struct {
+	txID   string
+	status *client.Status
+	err    error
+	done   chan struct{}
+}
+
+func (f *fakeCommit) TransactionID() string {
+	return f.txID
+}
+
+func (f *fakeCommit) Status(opts ...grpc.CallOption) (*client.Status, error) {
+	<-f.done
+	return f.status, f.err
+}
+
+func waitForStatus(t *testing.T, tracker *transactionTracker, txID, status string) TransactionRecord {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		record, ok := tracker.Get(txID)
+		if ok && record.Status == status {
+			return record
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s to become %s, got %+v", txID, status, record)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTransactionTracker(t *testing.T) {
+	tracker := newTransactionTracker(time.Hour)
+
+	valid := &fakeCommit{
+		txID:   "tx1",
+		status: &client.Status{Code: peer.TxValidationCode_VALID, Successful: true, TransactionID: "tx1", BlockNumber: 42},
+		done:   make(chan struct{}),
+	}
+	if record := tracker.Track("CreateAsset", valid); record.Status != TransactionPending {
+		t.Fatalf("expected a pending transaction, got %+v", record)
+	}
+	close(valid.done)
+	record := waitForStatus(t, tracker, "tx1", TransactionValid)
+	if record.BlockNumber == nil || *record.BlockNumber != 42 || record.ValidationCode != "VALID" {
+		t.Errorf("expected block 42 and VALID, got %+v", record)
+	}
+
+	invalid := &fakeCommit{
+		txID:   "tx2",
+		status: &client.Status{Code: peer.TxValidationCode_MVCC_READ_CONFLICT, TransactionID: "tx2", BlockNumber: 43},
+		done:   make(chan struct{}),
+	}
+	tracker.Track("UpdateCompliance", invalid)
+	close(invalid.done)
+	record = waitForStatus(t, tracker, "tx2", TransactionInvalid)
+	if record.ValidationCode != "MVCC_READ_CONFLICT" {
+		t.Errorf("expected MVCC_READ_CONFLICT, got %+v", record)
+	}
+
+	timedOut := &fakeCommit{txID: "tx3", err: errors.New("deadline exceeded"), done: make(chan struct{})}
+	tracker.Track("UpdateCompliance", timedOut)
+	close(timedOut.done)
+	waitForStatus(t, tracker, "tx3", TransactionUnknown)
+}
+
+func TestTransactionTrackerPurgesFinished(t *testing.T) {
+	tracker := newTransactionTracker(time.Hour)
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	commit := &fakeCommit{txID: "old", status: &client.Status{Code: peer.TxValidationCode_VALID}, done: make(chan struct{})}
+	close(commit.done)
+	tracker.Track("CreateAsset", commit)
+	waitForStatus(t, tracker, "old", TransactionValid)
+
+	now = now.Add(2 * time.Hour)
+	tracker.Track("CreateAsset", &fakeCommit{txID: "new", done: make(chan struct{})})
+	if _, ok := tracker.Get("old"); ok {
+		t.Errorf("expected finished transaction past retention to be purged")
+	}
+	if _, ok := tracker.Get("new"); !ok {
+		t.Errorf("expected new transaction to be tracked")
+	}
+}
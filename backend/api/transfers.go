@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// TransferConfig lists the webhooks, such as the regulator's, told about
+// aircraft transfers between operators.
+type TransferConfig struct {
+	Webhooks []string `yaml:"webhooks"`
+}
+
+// TransferEvent is posted to the webhooks when a transfer is proposed,
+// accepted or cancelled. Result is what the chaincode returned.
+type TransferEvent struct {
+	Type         string          `json:"type"`
+	Registration string          `json:"registration"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Time         time.Time       `json:"time"`
+}
+
+var transferClient = &http.Client{Timeout: 10 * time.Second}
+
+// notifyTransfer posts a transfer event to the configured webhooks.
+func notifyTransfer(eventType, registration string, result []byte) {
+	event := TransferEvent{Type: eventType, Registration: registration, Time: time.Now().UTC()}
+	if json.Valid(result) {
+		event.Result = result
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal transfer event: %v", err)
+		return
+	}
+	for _, url := range appConfig.Transfers.Webhooks {
+		if err := postWebhook(transferClient, url, body); err != nil {
+			log.Printf("Failed to deliver %s event for %s to %s: %v", eventType, registration, url, err)
+		}
+	}
+}
+
+// proposeTransfer proposes moving an aircraft to the operator in the body.
+// It must be signed in as the current operator.
+func proposeTransfer(c *gin.Context) {
+	var request struct {
+		To string `json:"to"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil || request.To == "" {
+		respondError(c, http.StatusBadRequest, ErrCodeInvalidArgument, "to is required")
+		return
+	}
+
+	registration := c.Param("id")
+	response, err := getContract().SubmitWithContext(c.Request.Context(), "TransferAircraft", client.WithArguments(registration, request.To))
+	if err != nil {
+		respondGatewayError(c, "Failed to propose transfer", err)
+		return
+	}
+	go notifyTransfer("aircraft.transfer.proposed", registration, response)
+
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+// acceptTransfer completes the pending transfer of an aircraft. It must be
+// signed in as the new operator.
+func acceptTransfer(c *gin.Context) {
+	registration := c.Param("id")
+	response, err := getContract().SubmitWithContext(c.Request.Context(), "AcceptAircraftTransfer", client.WithArguments(registration))
+	if err != nil {
+		respondGatewayError(c, "Failed to accept transfer", err)
+		return
+	}
+	go notifyTransfer("aircraft.transfer.accepted", registration, response)
+
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+// cancelTransfer withdraws or declines the pending transfer of an aircraft.
+func cancelTransfer(c *gin.Context) {
+	registration := c.Param("id")
+	response, err := getContract().SubmitWithContext(c.Request.Context(), "CancelAircraftTransfer", client.WithArguments(registration))
+	if err != nil {
+		respondGatewayError(c, "Failed to cancel transfer", err)
+		return
+	}
+	go notifyTransfer("aircraft.transfer.cancelled", registration, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": string(response)})
+}
+
+func getPendingTransfers(c *gin.Context) {
+	response, err := getContract().EvaluateWithContext(c.Request.Context(), "GetPendingTransfers")
+	if err != nil {
+		respondGatewayError(c, "Failed to query chaincode", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
+
+// getAircraftAssets returns every report filed for an aircraft, including
+// those filed under earlier operators.
+func getAircraftAssets(c *gin.Context) {
+	response, err := getContract().EvaluateWithContext(c.Request.Context(), "GetAircraftAssets", client.WithArguments(c.Param("id")))
+	if err != nil {
+		respondGatewayError(c, "Failed to query chaincode", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", response)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotifyTransfer(t *testing.T) {
+	var events []TransferEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event TransferEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid webhook body: %v", err)
+		}
+		events = append(events, event)
+	}))
+	defer server.Close()
+
+	previousConfig := appConfig
+	defer func() { appConfig = previousConfig }()
+	appConfig = &Config{Transfers: TransferConfig{Webhooks: []string{server.URL}}}
+
+	notifyTransfer("aircraft.transfer.accepted", "PK-GFA", []byte(`{"registration":"PK-GFA","operator":"LNI"}`))
+	notifyTransfer("aircraft.transfer.cancelled", "PK-GFA", []byte("Transfer of PK-GFA cancelled"))
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != "aircraft.transfer.accepted" || string(events[0].Result) != `{"registration":"PK-GFA","operator":"LNI"}` {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+	if events[1].Registration != "PK-GFA" || events[1].Result != nil {
+		t.Errorf("expected a plain text result to be left out, got %+v", events[1])
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// regulatorMSPID is the organization of the aviation authority. It can be
+// changed with the REGULATOR_MSPID environment variable of the chaincode.
+var regulatorMSPID = "Org2MSP"
+
+func init() {
+	if mspID := os.Getenv("REGULATOR_MSPID"); mspID != "" {
+		regulatorMSPID = mspID
+	}
+}
+
+// submitterMSPID returns the MSP ID of the identity that submitted the
+// transaction
+func submitterMSPID(stub shim.ChaincodeStubInterface) (string, error) {
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return "", fmt.Errorf("Failed to read submitter: %s", err)
+	}
+
+	var identity msp.SerializedIdentity
+	if err := proto.Unmarshal(creator, &identity); err != nil {
+		return "", fmt.Errorf("Failed to parse submitter: %s", err)
+	}
+	return identity.Mspid, nil
+}
+
+// requireRegulator fails unless the transaction was submitted by the
+// regulator
+func requireRegulator(stub shim.ChaincodeStubInterface) error {
+	mspID, err := submitterMSPID(stub)
+	if err != nil {
+		return err
+	}
+	if mspID != regulatorMSPID {
+		return fmt.Errorf("Access denied: only the regulator (%s) may do this, not %s", regulatorMSPID, mspID)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
@@ -16,34 +17,71 @@ type SimpleChaincode struct{}
 type Asset struct {
 	ID          string `json:"id"`
 	CompanyName string `json:"companyName"`
+	AirlineID   string `json:"airlineId,omitempty"`
 	AircraftID  string `json:"aircraftId"`
 	Compliance  bool   `json:"compliance"`
 	ReportDate  string `json:"reportDate"`
 	Inspector   string `json:"inspector"`
 	Description string `json:"description"`
+	Review      string `json:"review,omitempty"`
+	DueDate     string `json:"dueDate,omitempty"`
+	Status      string `json:"status,omitempty"`
+	// InspectingOrg filed the report; it and the regulator endorse changes
+	InspectingOrg string `json:"inspectingOrg,omitempty"`
+	// InspectorLicense is the license of the inspector who filed the report
+	InspectorLicense string `json:"inspectorLicense,omitempty"`
+	// CheckType is the check reported on, e.g. A-check
+	CheckType string `json:"checkType,omitempty"`
 }
 
 // AssetHistory represents the history of an asset
 type AssetHistory struct {
+	TxID      string    `json:"txId"`
 	Timestamp time.Time `json:"timestamp"`
 	Asset     Asset     `json:"asset"`
 }
 
+// AssetRecord is an asset with the transaction that last wrote it
+type AssetRecord struct {
+	Asset     Asset     `json:"asset"`
+	TxID      string    `json:"txId"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // Init is called during chaincode instantiation to initialize the ledger
+// with sample airlines, their aircraft and an asset filed under each
 func (s *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	airlines := []Airline{
+		{ICAO: "ALA", Name: "Airline A", AOCNumber: "AOC-001", MSPID: "Org1MSP"},
+		{ICAO: "ALB", Name: "Airline B", AOCNumber: "AOC-002", MSPID: "Org1MSP"},
+	}
+	aircraft := []Aircraft{
+		{Registration: "A123", MSN: "1001", Type: "A320-214", Operator: "ALA"},
+		{Registration: "B456", MSN: "2002", Type: "737-8", Operator: "ALB"},
+	}
 	assets := []Asset{
-		{ID: "asset1", CompanyName: "Airline A", AircraftID: "A123", Compliance: true, ReportDate: "2024-01-01", Inspector: "Inspector1", Description: "Routine Check"},
-		{ID: "asset2", CompanyName: "Airline B", AircraftID: "B456", Compliance: false, ReportDate: "2024-02-15", Inspector: "Inspector2", Description: "Pending Maintenance"},
+		{ID: "asset1", CompanyName: "Airline A", AirlineID: "ALA", AircraftID: "A123", Compliance: true, ReportDate: "2024-01-01", Inspector: "Inspector1", Description: "Routine Check", InspectingOrg: "Org1MSP", CheckType: "A-check"},
+		{ID: "asset2", CompanyName: "Airline B", AirlineID: "ALB", AircraftID: "B456", Compliance: false, ReportDate: "2024-02-15", Inspector: "Inspector2", Description: "Pending Maintenance", InspectingOrg: "Org1MSP", CheckType: "C-check"},
 	}
 
-	for _, asset := range assets {
-		assetJSON, err := json.Marshal(asset)
-		if err != nil {
-			return shim.Error(fmt.Sprintf("Failed to marshal asset: %s", err))
+	for _, airline := range airlines {
+		if response := putMasterData(stub, airlineKeyType, airline.ICAO, airline); response.Status != shim.OK {
+			return response
 		}
-		err = stub.PutState(asset.ID, assetJSON)
-		if err != nil {
-			return shim.Error(fmt.Sprintf("Failed to add asset: %s", err))
+	}
+	since, err := txDate(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	for _, a := range aircraft {
+		a.OwnershipChain = []OperatorRecord{{Operator: a.Operator, Since: since, TxID: stub.GetTxID()}}
+		if response := putMasterData(stub, aircraftKeyType, a.Registration, a); response.Status != shim.OK {
+			return response
+		}
+	}
+	for _, asset := range assets {
+		if err := putAsset(stub, asset); err != nil {
+			return shim.Error(err.Error())
 		}
 	}
 	return shim.Success(nil)
@@ -62,17 +100,109 @@ func (s *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response
 		return s.UpdateCompliance(stub, args)
 	case "GetHistory":
 		return s.GetHistory(stub, args)
+	case "AssetExists":
+		return s.CheckAssetExists(stub, args)
+	case "GetAllAssets":
+		return s.GetAllAssets(stub, args)
+	case "RegisterOracle":
+		return s.RegisterOracle(stub, args)
+	case "GetOracle":
+		return s.GetOracle(stub, args)
+	case "CreateAttestedAsset":
+		return s.CreateAttestedAsset(stub, args)
+	case "GetAttestation":
+		return s.GetAttestation(stub, args)
+	case "ResolveReview":
+		return s.ResolveReview(stub, args)
+	case "MarkExpired":
+		return s.MarkExpired(stub, args)
+	case "GetDueAssets":
+		return s.GetDueAssets(stub, args)
+	case "PublishDirective":
+		return s.PublishDirective(stub, args)
+	case "GetDirective":
+		return s.GetDirective(stub, args)
+	case "GetAllDirectives":
+		return s.GetAllDirectives(stub, args)
+	case "RecordDirectiveCompliance":
+		return s.RecordDirectiveCompliance(stub, args)
+	case "GetDirectiveCompliance":
+		return s.GetDirectiveCompliance(stub, args)
+	case "GetOutstandingDirectives":
+		return s.GetOutstandingDirectives(stub, args)
+	case "CreateAirline":
+		return s.CreateAirline(stub, args)
+	case "ReadAirline":
+		return s.ReadAirline(stub, args)
+	case "UpdateAirline":
+		return s.UpdateAirline(stub, args)
+	case "DeleteAirline":
+		return s.DeleteAirline(stub, args)
+	case "GetAllAirlines":
+		return s.GetAllAirlines(stub, args)
+	case "GetAirlineHistory":
+		return s.GetAirlineHistory(stub, args)
+	case "CreateAircraft":
+		return s.CreateAircraft(stub, args)
+	case "ReadAircraft":
+		return s.ReadAircraft(stub, args)
+	case "UpdateAircraft":
+		return s.UpdateAircraft(stub, args)
+	case "DeleteAircraft":
+		return s.DeleteAircraft(stub, args)
+	case "GetAllAircraft":
+		return s.GetAllAircraft(stub, args)
+	case "GetAircraftHistory":
+		return s.GetAircraftHistory(stub, args)
+	case "TransferAircraft":
+		return s.TransferAircraft(stub, args)
+	case "AcceptAircraftTransfer":
+		return s.AcceptAircraftTransfer(stub, args)
+	case "CancelAircraftTransfer":
+		return s.CancelAircraftTransfer(stub, args)
+	case "GetPendingTransfers":
+		return s.GetPendingTransfers(stub, args)
+	case "GetAircraftAssets":
+		return s.GetAircraftAssets(stub, args)
+	case "GetEndorsingOrgs":
+		return s.GetEndorsingOrgs(stub, args)
+	case "RegisterInspector":
+		return s.RegisterInspector(stub, args)
+	case "UpdateInspector":
+		return s.UpdateInspector(stub, args)
+	case "ReadInspector":
+		return s.ReadInspector(stub, args)
+	case "GetAllInspectors":
+		return s.GetAllInspectors(stub, args)
+	case "GetInspectorHistory":
+		return s.GetInspectorHistory(stub, args)
 	default:
 		return shim.Error("Invalid function name")
 	}
 }
 
-// CreateAsset creates a new compliance report
+// CreateAsset creates a new compliance report. The company is the ICAO code
+// of a registered airline and the aircraft a registered aircraft it
+// operates. The 8th argument flags the report for review with the reason,
+// e.g. oracle sources that disagreed on the company name, the 9th sets the
+// date the next inspection is due and the 10th is the check type, which the
+// inspector's license must be rated for. The review and due date may be
+// empty, an empty review does not flag the report.
 func (s *SimpleChaincode) CreateAsset(stub shim.ChaincodeStubInterface, args []string) peer.Response {
-	if len(args) != 7 {
-		return shim.Error("Incorrect number of arguments. Expecting 7")
+	if len(args) != 10 {
+		return shim.Error("Incorrect number of arguments. Expecting 10")
 	}
 
+	asset, err := s.putNewAsset(stub, args)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(fmt.Sprintf("Asset %s created successfully", asset.ID)))
+}
+
+// putNewAsset stores the asset described by the CreateAsset arguments
+func (s *SimpleChaincode) putNewAsset(stub shim.ChaincodeStubInterface, args []string) (*Asset, error) {
 	id := args[0]
 	companyName := args[1]
 	aircraftID := args[2]
@@ -80,36 +210,83 @@ func (s *SimpleChaincode) CreateAsset(stub shim.ChaincodeStubInterface, args []s
 	inspector := args[4]
 	description := args[5]
 	compliance := args[6] == "true"
+	review := args[7]
+	dueDate := args[8]
+	if dueDate != "" {
+		if err := validateDueDate(dueDate); err != nil {
+			return nil, err
+		}
+	}
+	checkType := strings.TrimSpace(args[9])
+	if checkType == "" {
+		return nil, fmt.Errorf("Check type is required")
+	}
 
 	exists, err := s.AssetExists(stub, id)
 	if err != nil {
-		return shim.Error(fmt.Sprintf("Error checking asset existence: %s", err))
+		return nil, fmt.Errorf("Error checking asset existence: %s", err)
 	}
 	if exists {
-		return shim.Error(fmt.Sprintf("Asset %s already exists", id))
+		return nil, fmt.Errorf("Asset %s already exists", id)
+	}
+
+	airline, aircraft, err := resolveAssetReferences(stub, companyName, aircraftID)
+	if err != nil {
+		return nil, err
+	}
+	inspectingOrg, err := submitterMSPID(stub)
+	if err != nil {
+		return nil, err
+	}
+	license, err := requireLicensedInspector(stub, aircraft.Type, checkType)
+	if err != nil {
+		return nil, err
+	}
+	// The inspector is the one the license names, a report may not name
+	// another
+	if inspector != "" && !strings.EqualFold(strings.TrimSpace(inspector), license.Name) {
+		return nil, fmt.Errorf("Inspector %s does not match %s, the holder of license %s", inspector, license.Name, license.LicenseNumber)
 	}
 
 	asset := Asset{
-		ID:           id,
-		CompanyName:  companyName,
-		AircraftID:   aircraftID,
-		Compliance:   compliance,
-		ReportDate:   reportDate,
-		Inspector:    inspector,
-		Description:  description,
+		ID:               id,
+		CompanyName:      airline.Name,
+		AirlineID:        airline.ICAO,
+		AircraftID:       aircraft.Registration,
+		Compliance:       compliance,
+		ReportDate:       reportDate,
+		Inspector:        license.Name,
+		Description:      description,
+		Review:           review,
+		DueDate:          dueDate,
+		InspectingOrg:    inspectingOrg,
+		InspectorLicense: license.LicenseNumber,
+		CheckType:        checkType,
+	}
+	if err := requireNotPastDue(stub, asset); err != nil {
+		return nil, err
+	}
+
+	if err := putAsset(stub, asset); err != nil {
+		return nil, err
 	}
 
+	return &asset, nil
+}
+
+// putAsset stores a new asset under the endorsement policy of the regulator
+// and its inspecting organization
+func putAsset(stub shim.ChaincodeStubInterface, asset Asset) error {
 	assetJSON, err := json.Marshal(asset)
 	if err != nil {
-		return shim.Error(fmt.Sprintf("Error marshalling asset: %s", err))
+		return fmt.Errorf("Error marshalling asset: %s", err)
 	}
 
-	err = stub.PutState(id, assetJSON)
+	err = stub.PutState(asset.ID, assetJSON)
 	if err != nil {
-		return shim.Error(fmt.Sprintf("Error storing asset: %s", err))
+		return fmt.Errorf("Error storing asset: %s", err)
 	}
-
-	return shim.Success([]byte(fmt.Sprintf("Asset %s created successfully", id)))
+	return setAssetEndorsement(stub, asset.ID, asset.InspectingOrg)
 }
 
 // ReadAsset retrieves an asset from the ledger
@@ -130,14 +307,21 @@ func (s *SimpleChaincode) ReadAsset(stub shim.ChaincodeStubInterface, args []str
 	return shim.Success(assetJSON)
 }
 
-// UpdateCompliance updates the compliance status of an asset
+// UpdateCompliance updates the compliance status of an asset. An optional
+// 3rd argument records a new inspection by setting the next due date, which
+// also lifts an expiry. An asset cannot be made compliant past its due date.
 func (s *SimpleChaincode) UpdateCompliance(stub shim.ChaincodeStubInterface, args []string) peer.Response {
-	if len(args) != 2 {
-		return shim.Error("Incorrect number of arguments. Expecting 2")
+	if len(args) != 2 && len(args) != 3 {
+		return shim.Error("Incorrect number of arguments. Expecting 2 or 3")
 	}
 
 	id := args[0]
 	compliance := args[1] == "true"
+	if len(args) == 3 {
+		if err := validateDueDate(args[2]); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
 
 	assetJSON, err := stub.GetState(id)
 	if err != nil {
@@ -154,6 +338,58 @@ func (s *SimpleChaincode) UpdateCompliance(stub shim.ChaincodeStubInterface, arg
 	}
 
 	asset.Compliance = compliance
+	if len(args) == 3 {
+		asset.DueDate = args[2]
+		asset.Status = ""
+	}
+	if err := requireNotPastDue(stub, asset); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := ensureAssetEndorsement(stub, &asset); err != nil {
+		return shim.Error(err.Error())
+	}
+	assetJSON, err = json.Marshal(asset)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal updated asset: %s", err))
+	}
+
+	err = stub.PutState(id, assetJSON)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to store updated asset: %s", err))
+	}
+
+	return shim.Success(nil)
+}
+
+// ResolveReview clears the review flag of an asset. Only the regulator may
+// resolve reviews.
+func (s *SimpleChaincode) ResolveReview(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	if err := requireRegulator(stub); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	id := args[0]
+	assetJSON, err := stub.GetState(id)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read asset: %s", err))
+	}
+	if assetJSON == nil {
+		return shim.Error(fmt.Sprintf("Asset %s does not exist", id))
+	}
+
+	var asset Asset
+	err = json.Unmarshal(assetJSON, &asset)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to unmarshal asset: %s", err))
+	}
+	if asset.Review == "" {
+		return shim.Error(fmt.Sprintf("Asset %s is not flagged for review", id))
+	}
+
+	asset.Review = ""
 	assetJSON, err = json.Marshal(asset)
 	if err != nil {
 		return shim.Error(fmt.Sprintf("Failed to marshal updated asset: %s", err))
@@ -196,6 +432,7 @@ func (s *SimpleChaincode) GetHistory(stub shim.ChaincodeStubInterface, args []st
 		}
 
 		history = append(history, AssetHistory{
+			TxID:      response.TxId,
 			Timestamp: time.Unix(response.Timestamp.Seconds, int64(response.Timestamp.Nanos)),
 			Asset:     asset,
 		})
@@ -209,6 +446,85 @@ func (s *SimpleChaincode) GetHistory(stub shim.ChaincodeStubInterface, args []st
 	return shim.Success(historyJSON)
 }
 
+// GetAllAssets returns every asset together with the transaction that last
+// wrote it, so exports can point back at the ledger
+func (s *SimpleChaincode) GetAllAssets(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 0 {
+		return shim.Error("Incorrect number of arguments. Expecting 0")
+	}
+
+	resultsIterator, err := stub.GetStateByRange("", "")
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read assets: %s", err))
+	}
+	defer resultsIterator.Close()
+
+	records := []AssetRecord{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Error iterating assets: %s", err))
+		}
+
+		var record AssetRecord
+		if err := json.Unmarshal(result.Value, &record.Asset); err != nil {
+			return shim.Error(fmt.Sprintf("Failed to unmarshal asset: %s", err))
+		}
+		record.TxID, record.Timestamp, err = lastModification(stub, result.Key)
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Failed to retrieve history: %s", err))
+		}
+		records = append(records, record)
+	}
+
+	recordsJSON, err := json.Marshal(records)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal assets: %s", err))
+	}
+
+	return shim.Success(recordsJSON)
+}
+
+// lastModification returns the latest transaction that wrote key. Fabric
+// returns the history of a key newest first, so only its first entry is
+// read.
+func lastModification(stub shim.ChaincodeStubInterface, key string) (string, time.Time, error) {
+	resultsIterator, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resultsIterator.Close()
+
+	if !resultsIterator.HasNext() {
+		return "", time.Time{}, nil
+	}
+	modification, err := resultsIterator.Next()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return modification.TxId, time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos)), nil
+}
+
+// CheckAssetExists reports whether an asset exists as a JSON boolean. It is
+// also the cheap query the API uses to check that the chaincode is reachable.
+func (s *SimpleChaincode) CheckAssetExists(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	exists, err := s.AssetExists(stub, args[0])
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read asset: %s", err))
+	}
+
+	existsJSON, err := json.Marshal(exists)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal response: %s", err))
+	}
+
+	return shim.Success(existsJSON)
+}
+
 // AssetExists checks if an asset exists
 func (s *SimpleChaincode) AssetExists(stub shim.ChaincodeStubInterface, id string) (bool, error) {
 	assetJSON, err := stub.GetState(id)
@@ -0,0 +1,934 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCreateAsset tests the CreateAsset function
+func TestCreateAsset(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+
+	registerMasterData(t, mockStub)
+
+	// Case 1: Asset creation success
+	assetID := "asset1"
+	args := [][]byte{
+		[]byte("CreateAsset"),
+		[]byte(assetID), []byte("GIA"), []byte("PK-GFA"),
+		[]byte("2024-12-01"), []byte("Inspector Y"), []byte("Passed Safety Check"),
+		[]byte("true"), []byte(""), []byte(""), []byte("A-check"),
+	}
+	response := mockStub.MockInvoke("1", args)
+
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAsset to succeed")
+	assert.Empty(t, response.Message, "Expected no error message")
+
+	// Verify asset in state
+	state := mockStub.State[assetID]
+	assert.NotNil(t, state, "Expected asset to be stored")
+	var asset Asset
+	err := json.Unmarshal(state, &asset)
+	assert.NoError(t, err, "Expected unmarshalling asset to succeed")
+	assert.Equal(t, assetID, asset.ID)
+	assert.Equal(t, "Garuda Indonesia", asset.CompanyName)
+	assert.Equal(t, "GIA", asset.AirlineID)
+
+	// Case 2: Unknown airlines and aircraft, airline names and aircraft of
+	// another airline are rejected
+	for i, refs := range [][2]string{{"XXX", "PK-GFA"}, {"GIA", "A789"}, {"Garuda Indonesia", "PK-GFA"}, {"LNI", "PK-GFA"}} {
+		args[1], args[2], args[3] = []byte(fmt.Sprintf("asset%d", i+2)), []byte(refs[0]), []byte(refs[1])
+		response = mockStub.MockInvoke(fmt.Sprint(i+2), args)
+		assert.NotEqual(t, int32(shim.OK), response.Status, "Expected CreateAsset to reject %s and %s", refs[0], refs[1])
+	}
+}
+
+// TestReadAsset tests the ReadAsset function
+func TestReadAsset(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+
+	// Initialize ledger with default assets
+	mockStub.MockInit("1", [][]byte{[]byte("Init")})
+
+	// Case 1: Read existing asset
+	response := mockStub.MockInvoke("1", [][]byte{
+		[]byte("ReadAsset"), []byte("asset1"),
+	})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected ReadAsset to succeed")
+
+	var asset Asset
+	err := json.Unmarshal(response.Payload, &asset)
+	assert.NoError(t, err, "Expected unmarshalling asset to succeed")
+	assert.Equal(t, "asset1", asset.ID)
+
+	// Case 2: Read non-existent asset
+	response = mockStub.MockInvoke("2", [][]byte{
+		[]byte("ReadAsset"), []byte("nonexistent"),
+	})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected ReadAsset to fail for non-existent asset")
+}
+
+// TestUpdateCompliance tests the UpdateCompliance function
+func TestUpdateCompliance(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+
+	// Initialize ledger with default assets
+	mockStub.MockInit("1", [][]byte{[]byte("Init")})
+
+	// Case 1: Update compliance status
+	assetID := "asset1"
+	args := [][]byte{[]byte("UpdateCompliance"), []byte(assetID), []byte("false")}
+	response := mockStub.MockInvoke("1", args)
+
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected UpdateCompliance to succeed")
+	assert.Empty(t, response.Message, "Expected no error message")
+
+	// Verify compliance update
+	state := mockStub.State[assetID]
+	assert.NotNil(t, state, "Expected asset to be present")
+	var asset Asset
+	err := json.Unmarshal(state, &asset)
+	assert.NoError(t, err, "Expected unmarshalling asset to succeed")
+	assert.False(t, asset.Compliance)
+}
+
+// historyStub serves the revisions recorded by the test as key history,
+// which MockStub does not implement
+type historyStub struct {
+	*shimtest.MockStub
+	history map[string][]*queryresult.KeyModification
+}
+
+// record adds the current value of a key as written by transaction txID
+func (s *historyStub) record(txID, key string) {
+	if s.history == nil {
+		s.history = map[string][]*queryresult.KeyModification{}
+	}
+	timestamp, _ := s.GetTxTimestamp()
+	s.history[key] = append(s.history[key], &queryresult.KeyModification{
+		TxId:      txID,
+		Value:     s.State[key],
+		Timestamp: timestamp,
+		IsDelete:  s.State[key] == nil,
+	})
+}
+
+// GetHistoryForKey returns the recorded revisions newest first, as Fabric does
+func (s *historyStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	revisions := s.history[key]
+	iterator := &historyIterator{}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		iterator.revisions = append(iterator.revisions, revisions[i])
+	}
+	return iterator, nil
+}
+
+type historyIterator struct {
+	revisions []*queryresult.KeyModification
+}
+
+func (i *historyIterator) HasNext() bool {
+	return len(i.revisions) > 0
+}
+
+func (i *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if len(i.revisions) == 0 {
+		return nil, fmt.Errorf("no more revisions")
+	}
+	next := i.revisions[0]
+	i.revisions = i.revisions[1:]
+	return next, nil
+}
+
+func (i *historyIterator) Close() error {
+	return nil
+}
+
+// TestGetHistory tests the GetHistory function
+func TestGetHistory(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+	stub := &historyStub{MockStub: mockStub}
+
+	// Initialize ledger with default assets
+	mockStub.MockInit("1", [][]byte{[]byte("Init")})
+	stub.record("1", "asset1")
+
+	// Update compliance status to create history
+	for i, compliance := range []string{"false", "true"} {
+		txID := fmt.Sprint(i + 2)
+		response := mockStub.MockInvoke(txID, [][]byte{[]byte("UpdateCompliance"), []byte("asset1"), []byte(compliance)})
+		assert.Equal(t, int32(shim.OK), response.Status, "Expected UpdateCompliance to succeed")
+		stub.record(txID, "asset1")
+	}
+
+	// Retrieve history for asset1 through the recorded revisions
+	response := chaincode.GetHistory(stub, []string{"asset1"})
+
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected GetHistory to succeed")
+
+	var history []AssetHistory
+	err := json.Unmarshal(response.Payload, &history)
+	assert.NoError(t, err, "Expected unmarshalling history to succeed")
+	if assert.Len(t, history, 3, "Expected a history entry per write") {
+		assert.Equal(t, "3", history[0].TxID, "Expected the latest revision first")
+		assert.True(t, history[0].Asset.Compliance)
+		assert.False(t, history[1].Asset.Compliance)
+	}
+}
+
+// TestGetAllAssets tests that assets carry the transaction that last wrote
+// them
+func TestGetAllAssets(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+	stub := &historyStub{MockStub: mockStub}
+
+	for i, compliance := range []bool{true, false} {
+		txID := fmt.Sprint(i + 1)
+		assetJSON, _ := json.Marshal(Asset{ID: "asset1", Compliance: compliance})
+		mockStub.MockTransactionStart(txID)
+		assert.NoError(t, mockStub.PutState("asset1", assetJSON))
+		mockStub.MockTransactionEnd(txID)
+		stub.record(txID, "asset1")
+	}
+
+	response := chaincode.GetAllAssets(stub, []string{})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected GetAllAssets to succeed: %s", response.Message)
+	var records []AssetRecord
+	assert.NoError(t, json.Unmarshal(response.Payload, &records), "Expected unmarshalling assets to succeed")
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "2", records[0].TxID, "Expected the latest transaction")
+		assert.False(t, records[0].Asset.Compliance)
+	}
+}
+
+// TestAssetExists tests the AssetExists function
+func TestAssetExists(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+
+	// Initialize ledger with default assets
+	mockStub.MockInit("1", [][]byte{[]byte("Init")})
+
+	// Case 1: Existing asset
+	response := mockStub.MockInvoke("1", [][]byte{[]byte("AssetExists"), []byte("asset1")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected AssetExists to succeed")
+	assert.Equal(t, "true", string(response.Payload))
+
+	// Case 2: Non-existent asset
+	response = mockStub.MockInvoke("2", [][]byte{[]byte("AssetExists"), []byte("nonexistent")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected AssetExists to succeed")
+	assert.Equal(t, "false", string(response.Payload))
+}
+
+// setCreator makes the following transactions come from mspID
+func setCreator(t *testing.T, mockStub *shimtest.MockStub, mspID string) {
+	setCreatorCN(t, mockStub, mspID, "inspector")
+}
+
+// setCreatorCN submits the next transactions as the holder of a certificate
+// with the given common name
+func setCreatorCN(t *testing.T, mockStub *shimtest.MockStub, mspID, commonName string) {
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: testCertificate(t, mspID, commonName)})
+	assert.NoError(t, err, "Expected marshalling creator to succeed")
+	mockStub.Creator = creator
+}
+
+// testCertificate returns a self-signed PEM certificate for a user of an MSP
+func testCertificate(t *testing.T, mspID, commonName string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{mspID}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err, "Expected creating certificate to succeed")
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// registerMasterData registers the airlines and aircraft the tests file
+// reports under, and licenses the inspector of each organization
+func registerMasterData(t *testing.T, mockStub *shimtest.MockStub) {
+	setCreator(t, mockStub, regulatorMSPID)
+	for _, airline := range []Airline{
+		{ICAO: "GIA", IATA: "GA", Name: "Garuda Indonesia", AOCNumber: "AOC 121-001", MSPID: "Org1MSP"},
+		{ICAO: "LNI", IATA: "JT", Name: "Lion Air", AOCNumber: "AOC 121-010", MSPID: "Org3MSP"},
+	} {
+		airlineJSON, _ := json.Marshal(airline)
+		response := mockStub.MockInvoke("airline-"+airline.ICAO, [][]byte{[]byte("CreateAirline"), airlineJSON})
+		assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAirline to succeed: %s", response.Message)
+	}
+	for _, aircraft := range []Aircraft{
+		{Registration: "PK-GFA", MSN: "35208", Type: "737-86N", Operator: "GIA"},
+		{Registration: "PK-LKS", MSN: "38729", Type: "737-9GP(ER)", Operator: "LNI"},
+	} {
+		aircraftJSON, _ := json.Marshal(aircraft)
+		response := mockStub.MockInvoke("aircraft-"+aircraft.Registration, [][]byte{[]byte("CreateAircraft"), aircraftJSON})
+		assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAircraft to succeed: %s", response.Message)
+	}
+	for i, mspID := range []string{"Org1MSP", "Org3MSP", regulatorMSPID} {
+		inspectorJSON, _ := json.Marshal(Inspector{
+			LicenseNumber:    fmt.Sprintf("AMEL-%d", i+1),
+			Name:             "Inspector Y",
+			IssuingAuthority: "DGCA",
+			Ratings:          InspectorRatings{AircraftTypes: []string{"737-*", "A320-*"}, CheckTypes: []string{"A-check", "C-check"}},
+			ValidFrom:        "2020-01-01",
+			ValidUntil:       "2999-12-31",
+			MSPID:            mspID,
+			Certificate:      string(testCertificate(t, mspID, "inspector")),
+		})
+		response := mockStub.MockInvoke(fmt.Sprintf("inspector-%d", i+1), [][]byte{[]byte("RegisterInspector"), inspectorJSON})
+		assert.Equal(t, int32(shim.OK), response.Status, "Expected RegisterInspector to succeed: %s", response.Message)
+	}
+}
+
+// signAttestation signs an attestation the way the oracle does
+func signAttestation(t *testing.T, key *ecdsa.PrivateKey, attestation Attestation) Attestation {
+	digest := sha256.Sum256(attestationPayload(attestation))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.NoError(t, err, "Expected signing to succeed")
+	attestation.Signature = base64.StdEncoding.EncodeToString(signature)
+	return attestation
+}
+
+// TestCreateAttestedAsset tests RegisterOracle and CreateAttestedAsset
+func TestCreateAttestedAsset(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})
+	registerMasterData(t, mockStub)
+
+	// Case 1: Only the regulator registers oracles
+	setCreator(t, mockStub, "Org1MSP")
+	response := mockStub.MockInvoke("1", [][]byte{[]byte("RegisterOracle"), []byte("oracle1"), publicKeyPEM})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected RegisterOracle to fail for Org1MSP")
+
+	setCreator(t, mockStub, regulatorMSPID)
+	response = mockStub.MockInvoke("2", [][]byte{[]byte("RegisterOracle"), []byte("oracle1"), []byte("not a key")})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected RegisterOracle to reject an invalid key")
+	response = mockStub.MockInvoke("3", [][]byte{[]byte("RegisterOracle"), []byte("oracle1"), publicKeyPEM})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected RegisterOracle to succeed for the regulator")
+
+	attestation := signAttestation(t, key, Attestation{
+		AssetID:      "asset1",
+		OracleID:     "oracle1",
+		Provider:     "registry",
+		Request:      "PK-GFA",
+		Result:       "Garuda Indonesia",
+		ResponseHash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		FetchedAt:    "2024-12-01T08:00:00Z",
+	})
+	invoke := func(txID, assetID, airlineID string, attestation Attestation) peer.Response {
+		attestationJSON, _ := json.Marshal(attestation)
+		return mockStub.MockInvoke(txID, [][]byte{
+			[]byte("CreateAttestedAsset"),
+			[]byte(assetID), []byte(airlineID), []byte("PK-GFA"),
+			[]byte("2024-12-01"), []byte("Inspector Y"), []byte("Passed Safety Check"),
+			[]byte("true"), attestationJSON, []byte(""), []byte(""), []byte("A-check"),
+		})
+	}
+
+	// Case 2: The attestation must name the registered airline and carry a
+	// valid signature
+	response = invoke("4", "asset1", "LNI", attestation)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a different company name to be rejected")
+
+	tampered := attestation
+	tampered.Result = "Lion Air"
+	response = invoke("5", "asset1", "LNI", tampered)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a tampered attestation to be rejected")
+
+	unknown := attestation
+	unknown.OracleID = "oracle2"
+	response = invoke("6", "asset1", "GIA", unknown)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected an unregistered oracle to be rejected")
+	assert.Nil(t, mockStub.State["asset1"], "Expected no asset to be stored for rejected attestations")
+
+	// Case 3: A valid attestation is stored with the asset
+	response = invoke("7", "asset1", "GIA", attestation)
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAttestedAsset to succeed: %s", response.Message)
+
+	response = mockStub.MockInvoke("8", [][]byte{[]byte("GetAttestation"), []byte("asset1")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected GetAttestation to succeed")
+	var stored Attestation
+	err = json.Unmarshal(response.Payload, &stored)
+	assert.NoError(t, err, "Expected unmarshalling attestation to succeed")
+	assert.Equal(t, "asset1", stored.AssetID)
+	assert.Equal(t, attestation.Signature, stored.Signature)
+
+	// Case 4: An attestation cannot be replayed for another asset
+	response = invoke("9", "asset2", "GIA", attestation)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected an attestation for another asset to be rejected")
+	replayed := attestation
+	replayed.AssetID = "asset2"
+	response = invoke("10", "asset2", "GIA", replayed)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected an attestation re-addressed to another asset to be rejected")
+	assert.Nil(t, mockStub.State["asset2"], "Expected no asset to be stored for replayed attestations")
+
+	// Case 5: The aircraft is compared as registered, but must be the same
+	other := attestation
+	other.AssetID, other.Request = "asset3", "PK-LKS"
+	response = invoke("11", "asset3", "GIA", signAttestation(t, key, other))
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected an attestation for another aircraft to be rejected")
+	lower := attestation
+	lower.AssetID, lower.Request = "asset3", " pk-gfa"
+	response = invoke("12", "asset3", "GIA", signAttestation(t, key, lower))
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected the registration to be compared regardless of case: %s", response.Message)
+}
+
+// TestCreateAssetForReview tests flagging a report for review and ResolveReview
+func TestCreateAssetForReview(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+	registerMasterData(t, mockStub)
+
+	// Case 1: The 8th argument flags the report
+	response := mockStub.MockInvoke("1", [][]byte{
+		[]byte("CreateAsset"),
+		[]byte("asset1"), []byte("GIA"), []byte("PK-GFA"),
+		[]byte("2024-12-01"), []byte("Inspector Y"), []byte("Passed Safety Check"),
+		[]byte("true"), []byte("oracle sources disagree on airline_name"), []byte(""), []byte("A-check"),
+	})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAsset to succeed: %s", response.Message)
+
+	var asset Asset
+	err := json.Unmarshal(mockStub.State["asset1"], &asset)
+	assert.NoError(t, err, "Expected unmarshalling asset to succeed")
+	assert.Equal(t, "oracle sources disagree on airline_name", asset.Review)
+
+	// Case 2: Only the regulator resolves the review
+	setCreator(t, mockStub, "Org1MSP")
+	response = mockStub.MockInvoke("2", [][]byte{[]byte("ResolveReview"), []byte("asset1")})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected ResolveReview to fail for Org1MSP")
+
+	setCreator(t, mockStub, regulatorMSPID)
+	response = mockStub.MockInvoke("3", [][]byte{[]byte("ResolveReview"), []byte("asset1")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected ResolveReview to succeed: %s", response.Message)
+
+	var resolved Asset
+	err = json.Unmarshal(mockStub.State["asset1"], &resolved)
+	assert.NoError(t, err, "Expected unmarshalling asset to succeed")
+	assert.Empty(t, resolved.Review)
+
+	response = mockStub.MockInvoke("4", [][]byte{[]byte("ResolveReview"), []byte("asset1")})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected ResolveReview to fail for an unflagged asset")
+}
+
+// TestComplianceExpiry tests due dates, MarkExpired and GetDueAssets
+func TestComplianceExpiry(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+	registerMasterData(t, mockStub)
+
+	create := func(txID, assetID, compliance, dueDate string) peer.Response {
+		return mockStub.MockInvoke(txID, [][]byte{
+			[]byte("CreateAsset"),
+			[]byte(assetID), []byte("GIA"), []byte("PK-GFA"),
+			[]byte("2024-12-01"), []byte("Inspector Y"), []byte("A-check"),
+			[]byte(compliance), []byte(""), []byte(dueDate), []byte("A-check"),
+		})
+	}
+
+	// Case 1: A report cannot be compliant past its due date
+	response := create("1", "overdue", "true", "2000-01-01")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a compliant report past due to be rejected")
+	response = create("2", "overdue", "false", "2000-01-01")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected a non-compliant report past due to succeed: %s", response.Message)
+	response = create("3", "current", "true", "2999-01-01")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAsset to succeed: %s", response.Message)
+	response = create("4", "invalid", "true", "next year")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected an invalid due date to be rejected")
+
+	response = mockStub.MockInvoke("5", [][]byte{[]byte("UpdateCompliance"), []byte("overdue"), []byte("true")})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected UpdateCompliance to refuse compliance past due")
+
+	// Case 2: Only assets past due are listed as due and can be expired
+	response = mockStub.MockInvoke("6", [][]byte{[]byte("GetDueAssets"), []byte("2024-12-31")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected GetDueAssets to succeed: %s", response.Message)
+	var due []Asset
+	err := json.Unmarshal(response.Payload, &due)
+	assert.NoError(t, err, "Expected unmarshalling assets to succeed")
+	assert.Len(t, due, 1)
+
+	response = mockStub.MockInvoke("7", [][]byte{[]byte("MarkExpired"), []byte("current")})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected MarkExpired to refuse an asset not yet due")
+	response = mockStub.MockInvoke("8", [][]byte{[]byte("MarkExpired"), []byte("overdue")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected MarkExpired to succeed: %s", response.Message)
+
+	var asset Asset
+	err = json.Unmarshal(mockStub.State["overdue"], &asset)
+	assert.NoError(t, err, "Expected unmarshalling asset to succeed")
+	assert.Equal(t, StatusExpired, asset.Status)
+	assert.False(t, asset.Compliance)
+
+	// Case 3: A new inspection with a new due date lifts the expiry
+	response = mockStub.MockInvoke("9", [][]byte{[]byte("UpdateCompliance"), []byte("overdue"), []byte("true"), []byte("2999-06-01")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected UpdateCompliance to succeed: %s", response.Message)
+	var renewed Asset
+	err = json.Unmarshal(mockStub.State["overdue"], &renewed)
+	assert.NoError(t, err, "Expected unmarshalling asset to succeed")
+	assert.Empty(t, renewed.Status)
+	assert.True(t, renewed.Compliance)
+	assert.Equal(t, "2999-06-01", renewed.DueDate)
+}
+
+// TestDirectives tests publishing ADs and tracking per-aircraft compliance
+func TestDirectives(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+	mockStub.MockInit("1", [][]byte{[]byte("Init")})
+	registerMasterData(t, mockStub)
+
+	publish := func(txID string, directive Directive) peer.Response {
+		directiveJSON, _ := json.Marshal(directive)
+		return mockStub.MockInvoke(txID, [][]byte{[]byte("PublishDirective"), directiveJSON})
+	}
+	outstanding := func(txID, aircraftID string) []Directive {
+		response := mockStub.MockInvoke(txID, [][]byte{[]byte("GetOutstandingDirectives"), []byte(aircraftID)})
+		assert.Equal(t, int32(shim.OK), response.Status, "Expected GetOutstandingDirectives to succeed: %s", response.Message)
+		var directives []Directive
+		assert.NoError(t, json.Unmarshal(response.Payload, &directives), "Expected unmarshalling directives to succeed")
+		return directives
+	}
+	record := func(txID, aircraftID, directiveID string, assetID ...string) peer.Response {
+		args := [][]byte{[]byte("RecordDirectiveCompliance"),
+			[]byte(aircraftID), []byte(directiveID), []byte("2024-05-01"), []byte("Inspected")}
+		for _, id := range assetID {
+			args = append(args, []byte(id))
+		}
+		return mockStub.MockInvoke(txID, args)
+	}
+
+	rudder := Directive{ID: "AD-2024-01", Kind: DirectiveAD, Title: "Rudder control rod inspection",
+		AircraftTypes: []string{"737-*"}, EffectiveDate: "2024-03-01", ComplianceMethod: "Inspect per SB 737-27-1234"}
+	pitot := Directive{ID: "AD-2024-02", Kind: DirectiveAD, Title: "Pitot probe replacement",
+		AircraftTypes: []string{"A320-214"}, EffectiveDate: "2024-04-01", ComplianceMethod: "Replace probes"}
+	future := Directive{ID: "AD-2999-01", Kind: DirectiveAD, Title: "Not yet effective",
+		AircraftTypes: []string{"737-86N"}, EffectiveDate: "2999-01-01", ComplianceMethod: "Inspect"}
+
+	// Case 1: Only the regulator publishes directives
+	setCreator(t, mockStub, "Org1MSP")
+	response := publish("2", rudder)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected PublishDirective to fail for Org1MSP")
+
+	setCreator(t, mockStub, regulatorMSPID)
+	response = publish("3", Directive{ID: "AD-BAD", Kind: "XX", AircraftTypes: []string{"737-*"}, EffectiveDate: "2024-01-01", ComplianceMethod: "Inspect"})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected an unknown directive kind to be rejected")
+	for i, directive := range []Directive{rudder, pitot, future} {
+		response = publish(fmt.Sprint(4+i), directive)
+		assert.Equal(t, int32(shim.OK), response.Status, "Expected PublishDirective to succeed: %s", response.Message)
+	}
+	assert.Nil(t, mockStub.State[rudder.ID], "Expected directives to be kept apart from assets")
+
+	// Case 2: Directives in effect for the registered type are outstanding
+	// until complied with
+	owed := outstanding("7", "PK-GFA")
+	assert.Len(t, owed, 1)
+	assert.Equal(t, rudder.ID, owed[0].ID)
+	owed = outstanding("8", "A123")
+	assert.Len(t, owed, 1)
+	assert.Equal(t, pitot.ID, owed[0].ID)
+	response = mockStub.MockInvoke("8", [][]byte{[]byte("GetOutstandingDirectives"), []byte("PK-XXX")})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected an unregistered aircraft to be rejected")
+
+	// Case 3: Compliance is recorded for registered aircraft of the
+	// directive's type, citing a report on that aircraft
+	setCreator(t, mockStub, "Org1MSP")
+	response = record("9", "PK-GFA", "AD-UNKNOWN")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected compliance with an unknown directive to be rejected")
+	response = record("10", "PK-XXX", rudder.ID)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected compliance of an unregistered aircraft to be rejected")
+	response = record("11", "A123", rudder.ID)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected compliance with a directive for another type to be rejected")
+	response = record("12", "PK-GFA", rudder.ID, "asset1")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a report on another aircraft to be rejected")
+
+	// Case 4: Only the operator's organization or the one that inspected the
+	// aircraft records its compliance
+	setCreator(t, mockStub, "Org3MSP")
+	response = record("13", "PK-GFA", rudder.ID)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected Org3MSP to be refused compliance of a GIA aircraft")
+	response = mockStub.MockInvoke("14", [][]byte{
+		[]byte("CreateAsset"),
+		[]byte("gfa-rudder"), []byte("GIA"), []byte("PK-GFA"),
+		[]byte("2024-05-01"), []byte("Inspector Y"), []byte("Rudder control rod inspected"),
+		[]byte("true"), []byte(""), []byte(""), []byte("A-check"),
+	})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAsset to succeed: %s", response.Message)
+	response = record("15", "PK-GFA", rudder.ID, "gfa-rudder")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected RecordDirectiveCompliance to succeed: %s", response.Message)
+
+	setCreator(t, mockStub, "Org1MSP")
+	response = record("16", "A123", pitot.ID)
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected RecordDirectiveCompliance to succeed: %s", response.Message)
+
+	assert.Empty(t, outstanding("17", "PK-GFA"))
+	assert.Empty(t, outstanding("18", "A123"))
+	assert.Len(t, outstanding("19", "PK-LKS"), 1, "Expected compliance to be tracked per aircraft")
+
+	response = mockStub.MockInvoke("20", [][]byte{[]byte("GetDirectiveCompliance"), []byte("PK-GFA")})
+	var records []DirectiveCompliance
+	assert.NoError(t, json.Unmarshal(response.Payload, &records), "Expected unmarshalling records to succeed")
+	assert.Len(t, records, 1)
+	assert.Equal(t, "Org3MSP", records[0].RecordedBy)
+	assert.Equal(t, "gfa-rudder", records[0].AssetID)
+
+	response = mockStub.MockInvoke("21", [][]byte{[]byte("GetDirectiveCompliance"), []byte(" pk-gfa")})
+	assert.NoError(t, json.Unmarshal(response.Payload, &records), "Expected unmarshalling records to succeed")
+	assert.Len(t, records, 1, "Expected the registration to be looked up regardless of case")
+}
+
+// TestMasterData tests airline and aircraft records and who may write them
+func TestMasterData(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+	registerMasterData(t, mockStub)
+
+	invoke := func(txID, fn string, value interface{}) peer.Response {
+		valueJSON, _ := json.Marshal(value)
+		return mockStub.MockInvoke(txID, [][]byte{[]byte(fn), valueJSON})
+	}
+
+	// Case 1: Only the regulator registers airlines
+	setCreator(t, mockStub, "Org1MSP")
+	citilink := Airline{ICAO: "ctv", IATA: "QG", Name: "Citilink", AOCNumber: "AOC 121-046", MSPID: "Org1MSP"}
+	response := invoke("1", "CreateAirline", citilink)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected CreateAirline to fail for Org1MSP")
+
+	setCreator(t, mockStub, regulatorMSPID)
+	response = invoke("2", "CreateAirline", Airline{ICAO: "GARUDA", Name: "Garuda", AOCNumber: "1", MSPID: "Org1MSP"})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected an invalid ICAO code to be rejected")
+	response = invoke("3", "CreateAirline", citilink)
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAirline to succeed: %s", response.Message)
+	response = invoke("4", "CreateAirline", citilink)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a duplicate airline to be rejected")
+
+	response = mockStub.MockInvoke("5", [][]byte{[]byte("ReadAirline"), []byte("CTV")})
+	var airline Airline
+	assert.NoError(t, json.Unmarshal(response.Payload, &airline), "Expected unmarshalling airline to succeed")
+	assert.Equal(t, "CTV", airline.ICAO)
+
+	// Case 2: The operator's organization registers its own aircraft only
+	setCreator(t, mockStub, "Org1MSP")
+	response = invoke("6", "CreateAircraft", Aircraft{Registration: "pk-gqa", MSN: "4123", Type: "A320-214", Operator: "CTV"})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAircraft to succeed: %s", response.Message)
+	response = invoke("7", "CreateAircraft", Aircraft{Registration: "PK-LQJ", MSN: "39821", Type: "737-9GP(ER)", Operator: "LNI"})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected Org1MSP to be refused aircraft of another airline")
+	response = invoke("8", "CreateAircraft", Aircraft{Registration: "PK-XXX", MSN: "1", Type: "A320-214", Operator: "AWQ"})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected an unknown operator to be rejected")
+
+	response = invoke("9", "UpdateAircraft", Aircraft{Registration: "PK-GQA", MSN: "4123", Type: "A320-216", Operator: "CTV"})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected UpdateAircraft to succeed: %s", response.Message)
+	response = invoke("10", "UpdateAircraft", Aircraft{Registration: "PK-GQA", MSN: "4123", Type: "A320-216", Operator: "GIA"})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected UpdateAircraft to refuse changing the operator")
+
+	response = mockStub.MockInvoke("11", [][]byte{[]byte("GetAllAircraft")})
+	var fleet []Aircraft
+	assert.NoError(t, json.Unmarshal(response.Payload, &fleet), "Expected unmarshalling aircraft to succeed")
+	assert.Len(t, fleet, 3)
+
+	// Case 3: Reports reference the airline by ICAO code, not by name
+	response = mockStub.MockInvoke("12", [][]byte{
+		[]byte("CreateAsset"),
+		[]byte("asset1"), []byte("Citilink"), []byte("pk-gqa"),
+		[]byte("2024-12-01"), []byte("Inspector Y"), []byte("Passed Safety Check"),
+		[]byte("true"), []byte(""), []byte(""), []byte("A-check"),
+	})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected CreateAsset to reject an airline name")
+	response = mockStub.MockInvoke("12", [][]byte{
+		[]byte("CreateAsset"),
+		[]byte("asset1"), []byte(" ctv "), []byte("pk-gqa"),
+		[]byte("2024-12-01"), []byte("Inspector Y"), []byte("Passed Safety Check"),
+		[]byte("true"), []byte(""), []byte(""), []byte("A-check"),
+	})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAsset to succeed: %s", response.Message)
+	var asset Asset
+	assert.NoError(t, json.Unmarshal(mockStub.State["asset1"], &asset), "Expected unmarshalling asset to succeed")
+	assert.Equal(t, "Citilink", asset.CompanyName)
+	assert.Equal(t, "CTV", asset.AirlineID)
+	assert.Equal(t, "PK-GQA", asset.AircraftID)
+
+	// Case 4: An airline is deleted only once it operates no aircraft
+	setCreator(t, mockStub, regulatorMSPID)
+	response = mockStub.MockInvoke("13", [][]byte{[]byte("DeleteAirline"), []byte("CTV")})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected DeleteAirline to refuse an airline with aircraft")
+	response = mockStub.MockInvoke("14", [][]byte{[]byte("DeleteAircraft"), []byte("PK-GQA")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected DeleteAircraft to succeed: %s", response.Message)
+	response = mockStub.MockInvoke("15", [][]byte{[]byte("DeleteAirline"), []byte("CTV")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected DeleteAirline to succeed: %s", response.Message)
+	response = mockStub.MockInvoke("16", [][]byte{[]byte("ReadAirline"), []byte("CTV")})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a deleted airline to be gone")
+}
+
+// TestTransferAircraft tests the two-step transfer of an aircraft to a new
+// operator
+func TestTransferAircraft(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+	registerMasterData(t, mockStub)
+
+	createAsset := func(txID, assetID, company string) peer.Response {
+		return mockStub.MockInvoke(txID, [][]byte{
+			[]byte("CreateAsset"),
+			[]byte(assetID), []byte(company), []byte("PK-GFA"),
+			[]byte("2024-12-01"), []byte("Inspector Y"), []byte("C-check"),
+			[]byte("true"), []byte(""), []byte(""), []byte("A-check"),
+		})
+	}
+	invoke := func(txID, mspID string, args ...string) peer.Response {
+		setCreator(t, mockStub, mspID)
+		invokeArgs := make([][]byte, len(args))
+		for i, arg := range args {
+			invokeArgs[i] = []byte(arg)
+		}
+		return mockStub.MockInvoke(txID, invokeArgs)
+	}
+
+	response := createAsset("1", "asset1", "GIA")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAsset to succeed: %s", response.Message)
+
+	// Case 1: Only the current operator proposes a transfer
+	response = invoke("2", "Org3MSP", "TransferAircraft", "PK-GFA", "LNI")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected TransferAircraft to fail for the new operator")
+	response = invoke("3", "Org1MSP", "TransferAircraft", "PK-GFA", "GIA")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a transfer to the current operator to be rejected")
+	response = invoke("4", "Org1MSP", "TransferAircraft", "PK-GFA", "LNI")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected TransferAircraft to succeed: %s", response.Message)
+	response = invoke("5", "Org1MSP", "TransferAircraft", "PK-GFA", "LNI")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a second pending transfer to be rejected")
+
+	response = invoke("6", regulatorMSPID, "GetPendingTransfers")
+	var pending []AircraftTransfer
+	assert.NoError(t, json.Unmarshal(response.Payload, &pending), "Expected unmarshalling transfers to succeed")
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "GIA", pending[0].From)
+
+	// Case 2: Only the new operator accepts it
+	response = invoke("7", "Org1MSP", "AcceptAircraftTransfer", "PK-GFA")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected AcceptAircraftTransfer to fail for the old operator")
+	response = invoke("8", "Org3MSP", "AcceptAircraftTransfer", "PK-GFA")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected AcceptAircraftTransfer to succeed: %s", response.Message)
+
+	var aircraft Aircraft
+	assert.NoError(t, json.Unmarshal(response.Payload, &aircraft), "Expected unmarshalling aircraft to succeed")
+	assert.Equal(t, "LNI", aircraft.Operator)
+	assert.Len(t, aircraft.OwnershipChain, 2)
+	assert.Equal(t, "GIA", aircraft.OwnershipChain[0].Operator)
+	assert.Equal(t, "LNI", aircraft.OwnershipChain[1].Operator)
+
+	event := <-mockStub.ChaincodeEventsChannel
+	assert.Equal(t, "AircraftTransferProposed", event.EventName)
+	event = <-mockStub.ChaincodeEventsChannel
+	assert.Equal(t, "AircraftTransferred", event.EventName)
+
+	// Case 3: Past reports follow the aircraft to the new operator
+	response = invoke("9", "Org3MSP", "GetAircraftAssets", "PK-GFA")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected GetAircraftAssets to succeed: %s", response.Message)
+	var assets []Asset
+	assert.NoError(t, json.Unmarshal(response.Payload, &assets), "Expected unmarshalling assets to succeed")
+	assert.Len(t, assets, 1)
+	response = invoke("10", "Org1MSP", "GetAircraftAssets", "PK-GFA")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected the old operator to be refused the reports")
+
+	assert.NotEqual(t, int32(shim.OK), createAsset("11", "asset2", "GIA").Status, "Expected reports under the old operator to be rejected")
+	assert.Equal(t, int32(shim.OK), createAsset("12", "asset2", "LNI").Status, "Expected reports under the new operator to succeed")
+
+	// Case 4: A pending transfer can be cancelled by the regulator
+	response = invoke("13", "Org3MSP", "TransferAircraft", "PK-GFA", "GIA")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected TransferAircraft to succeed: %s", response.Message)
+	response = invoke("14", "Org4MSP", "CancelAircraftTransfer", "PK-GFA")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected CancelAircraftTransfer to fail for an uninvolved organization")
+	response = invoke("15", regulatorMSPID, "CancelAircraftTransfer", "PK-GFA")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CancelAircraftTransfer to succeed: %s", response.Message)
+	response = invoke("16", "Org1MSP", "AcceptAircraftTransfer", "PK-GFA")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a cancelled transfer not to be accepted")
+}
+
+// TestAssetEndorsementPolicy tests the key-level endorsement policy of assets
+func TestAssetEndorsementPolicy(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+	mockStub.MockInit("1", [][]byte{[]byte("Init")})
+	registerMasterData(t, mockStub)
+
+	// Case 1: Assets filed by an inspecting organization need it and the regulator
+	setCreator(t, mockStub, "Org1MSP")
+	response := mockStub.MockInvoke("2", [][]byte{
+		[]byte("CreateAsset"),
+		[]byte("asset3"), []byte("GIA"), []byte("PK-GFA"),
+		[]byte("2024-12-01"), []byte("Inspector Y"), []byte("Passed Safety Check"),
+		[]byte("true"), []byte(""), []byte(""), []byte("A-check"),
+	})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAsset to succeed: %s", response.Message)
+
+	var asset Asset
+	assert.NoError(t, json.Unmarshal(mockStub.State["asset3"], &asset), "Expected unmarshalling asset to succeed")
+	assert.Equal(t, "Org1MSP", asset.InspectingOrg)
+
+	response = mockStub.MockInvoke("3", [][]byte{[]byte("GetEndorsingOrgs"), []byte("asset3")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected GetEndorsingOrgs to succeed: %s", response.Message)
+	var orgs []string
+	assert.NoError(t, json.Unmarshal(response.Payload, &orgs), "Expected unmarshalling organizations to succeed")
+	assert.ElementsMatch(t, []string{"Org1MSP", regulatorMSPID}, orgs)
+
+	// Case 2: Seeded assets are under the same policy
+	response = mockStub.MockInvoke("4", [][]byte{[]byte("GetEndorsingOrgs"), []byte("asset1")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected GetEndorsingOrgs to succeed: %s", response.Message)
+	assert.NoError(t, json.Unmarshal(response.Payload, &orgs), "Expected unmarshalling organizations to succeed")
+	assert.ElementsMatch(t, []string{"Org1MSP", regulatorMSPID}, orgs)
+
+	// Case 3: Assets stored without a key-level policy fall back to the
+	// chaincode's until the next compliance update sets one
+	legacy, _ := json.Marshal(Asset{ID: "legacy", CompanyName: "Lion Air", AircraftID: "PK-LKS", Compliance: true, ReportDate: "2023-01-01"})
+	mockStub.State["legacy"] = legacy
+	response = mockStub.MockInvoke("5", [][]byte{[]byte("GetEndorsingOrgs"), []byte("legacy")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected GetEndorsingOrgs to succeed: %s", response.Message)
+	assert.Equal(t, "[]", string(response.Payload))
+
+	setCreator(t, mockStub, "Org3MSP")
+	response = mockStub.MockInvoke("6", [][]byte{[]byte("UpdateCompliance"), []byte("legacy"), []byte("false")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected UpdateCompliance to succeed: %s", response.Message)
+	response = mockStub.MockInvoke("7", [][]byte{[]byte("GetEndorsingOrgs"), []byte("legacy")})
+	assert.NoError(t, json.Unmarshal(response.Payload, &orgs), "Expected unmarshalling organizations to succeed")
+	assert.ElementsMatch(t, []string{"Org3MSP", regulatorMSPID}, orgs)
+	assert.NoError(t, json.Unmarshal(mockStub.State["legacy"], &asset), "Expected unmarshalling asset to succeed")
+	assert.Equal(t, "Org3MSP", asset.InspectingOrg)
+
+	// Case 4: Marking such an asset expired sets its policy as well
+	overdue, _ := json.Marshal(Asset{ID: "legacy2", CompanyName: "Lion Air", AircraftID: "PK-LKS", ReportDate: "2023-01-01", DueDate: "2023-06-01"})
+	mockStub.State["legacy2"] = overdue
+	response = mockStub.MockInvoke("8", [][]byte{[]byte("MarkExpired"), []byte("legacy2")})
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected MarkExpired to succeed: %s", response.Message)
+	response = mockStub.MockInvoke("9", [][]byte{[]byte("GetEndorsingOrgs"), []byte("legacy2")})
+	assert.NoError(t, json.Unmarshal(response.Payload, &orgs), "Expected unmarshalling organizations to succeed")
+	assert.ElementsMatch(t, []string{"Org3MSP", regulatorMSPID}, orgs)
+	assert.NoError(t, json.Unmarshal(mockStub.State["legacy2"], &asset), "Expected unmarshalling asset to succeed")
+	assert.Equal(t, "Org3MSP", asset.InspectingOrg)
+	assert.Equal(t, StatusExpired, asset.Status)
+
+	response = mockStub.MockInvoke("10", [][]byte{[]byte("GetEndorsingOrgs"), []byte("nonexistent")})
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected GetEndorsingOrgs to fail for a non-existent asset")
+}
+
+// TestInspectors tests the inspector registry and the license checks of
+// CreateAsset
+func TestInspectors(t *testing.T) {
+	chaincode := new(SimpleChaincode)
+	mockStub := shimtest.NewMockStub("mockStub", chaincode)
+	registerMasterData(t, mockStub)
+
+	register := func(txID, fn string, inspector Inspector) peer.Response {
+		inspectorJSON, _ := json.Marshal(inspector)
+		return mockStub.MockInvoke(txID, [][]byte{[]byte(fn), inspectorJSON})
+	}
+	createAsset := func(txID, assetID, inspector string, checkType ...string) peer.Response {
+		if len(checkType) == 0 {
+			checkType = []string{"A-check"}
+		}
+		return mockStub.MockInvoke(txID, [][]byte{
+			[]byte("CreateAsset"),
+			[]byte(assetID), []byte("GIA"), []byte("PK-GFA"),
+			[]byte("2024-12-01"), []byte(inspector), []byte("Routine check"),
+			[]byte("true"), []byte(""), []byte(""), []byte(checkType[0]),
+		})
+	}
+	junior := Inspector{
+		LicenseNumber: "AMEL-9", Name: "Junior Inspector", IssuingAuthority: "DGCA",
+		Ratings:   InspectorRatings{AircraftTypes: []string{"A320-*"}, CheckTypes: []string{"A-check"}},
+		ValidFrom: "2020-01-01", ValidUntil: "2999-12-31", MSPID: "Org1MSP",
+		Certificate: string(testCertificate(t, "Org1MSP", "junior")),
+	}
+	lapsed := Inspector{
+		LicenseNumber: "AMEL-10", Name: "Lapsed Inspector", IssuingAuthority: "DGCA",
+		Ratings:   InspectorRatings{AircraftTypes: []string{"737-*"}, CheckTypes: []string{"A-check"}},
+		ValidFrom: "2015-01-01", ValidUntil: "2021-12-31", MSPID: "Org1MSP",
+		Certificate: string(testCertificate(t, "Org1MSP", "lapsed")),
+	}
+
+	// Case 1: Only the regulator licenses inspectors, one license per identity
+	setCreator(t, mockStub, "Org1MSP")
+	response := register("1", "RegisterInspector", junior)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected RegisterInspector to fail for Org1MSP")
+
+	setCreator(t, mockStub, regulatorMSPID)
+	response = register("2", "RegisterInspector", junior)
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected RegisterInspector to succeed: %s", response.Message)
+	response = register("3", "RegisterInspector", lapsed)
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected RegisterInspector to succeed: %s", response.Message)
+	duplicate := junior
+	duplicate.LicenseNumber = "AMEL-11"
+	response = register("4", "RegisterInspector", duplicate)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a second license for the same identity to be rejected")
+	unrated := junior
+	unrated.Ratings.CheckTypes = []string{" "}
+	response = register("4", "UpdateInspector", unrated)
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a license without check types to be rejected")
+
+	response = mockStub.MockInvoke("5", [][]byte{[]byte("ReadInspector"), []byte("AMEL-9")})
+	var stored Inspector
+	assert.NoError(t, json.Unmarshal(response.Payload, &stored), "Expected unmarshalling inspector to succeed")
+	assert.Equal(t, "x509::CN=junior,O=Org1MSP::CN=junior,O=Org1MSP", stored.Identity)
+	assert.Empty(t, stored.Certificate)
+
+	// Case 2: Reports need a current license rated for the aircraft type and
+	// the check
+	setCreatorCN(t, mockStub, "Org1MSP", "unlicensed")
+	assert.NotEqual(t, int32(shim.OK), createAsset("6", "asset1", "Unlicensed Inspector").Status, "Expected an unlicensed submitter to be rejected")
+	setCreatorCN(t, mockStub, "Org1MSP", "junior")
+	assert.NotEqual(t, int32(shim.OK), createAsset("7", "asset1", "Junior Inspector").Status, "Expected an inspector not rated for the type to be rejected")
+	setCreatorCN(t, mockStub, "Org1MSP", "lapsed")
+	assert.NotEqual(t, int32(shim.OK), createAsset("8", "asset1", "Lapsed Inspector").Status, "Expected an expired license to be rejected")
+	assert.Nil(t, mockStub.State["asset1"], "Expected no asset to be stored for rejected inspectors")
+
+	// Case 3: A license rated for the type lets the report through
+	setCreator(t, mockStub, regulatorMSPID)
+	junior.Ratings.AircraftTypes = append(junior.Ratings.AircraftTypes, "737-86N")
+	response = register("9", "UpdateInspector", junior)
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected UpdateInspector to succeed: %s", response.Message)
+
+	setCreatorCN(t, mockStub, "Org1MSP", "junior")
+	response = createAsset("10", "asset1", "Inspector Y")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a report naming another inspector to be rejected")
+	response = createAsset("10", "asset1", "Junior Inspector", "C-check")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a check the license is not rated for to be rejected")
+	response = createAsset("10", "asset1", "Junior Inspector", "")
+	assert.NotEqual(t, int32(shim.OK), response.Status, "Expected a report without a check type to be rejected")
+	response = createAsset("11", "asset1", "junior inspector", "a-check")
+	assert.Equal(t, int32(shim.OK), response.Status, "Expected CreateAsset to succeed: %s", response.Message)
+	var asset Asset
+	assert.NoError(t, json.Unmarshal(mockStub.State["asset1"], &asset), "Expected unmarshalling asset to succeed")
+	assert.Equal(t, "AMEL-9", asset.InspectorLicense)
+	assert.Equal(t, "Junior Inspector", asset.Inspector, "Expected the inspector named by the license to be stored")
+	assert.Equal(t, "a-check", asset.CheckType)
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	directiveKeyType           = "directive"
+	directiveComplianceKeyType = "directiveCompliance"
+)
+
+// Kinds of directive
+const (
+	DirectiveAD = "AD"
+	DirectiveSB = "SB"
+)
+
+// Directive is an Airworthiness Directive or Service Bulletin. It applies
+// to the aircraft types listed, where a type ending in * matches every type
+// starting with the rest, e.g. 737-* for all 737 variants.
+type Directive struct {
+	ID               string    `json:"id"`
+	Kind             string    `json:"kind"`
+	Title            string    `json:"title"`
+	AircraftTypes    []string  `json:"aircraftTypes"`
+	EffectiveDate    string    `json:"effectiveDate"`
+	ComplianceMethod string    `json:"complianceMethod"`
+	PublishedBy      string    `json:"publishedBy"`
+	PublishedAt      time.Time `json:"publishedAt"`
+}
+
+// DirectiveCompliance records that an aircraft complied with a directive
+type DirectiveCompliance struct {
+	DirectiveID string `json:"directiveId"`
+	AircraftID  string `json:"aircraftId"`
+	Date        string `json:"date"`
+	Method      string `json:"method"`
+	AssetID     string `json:"assetId,omitempty"`
+	RecordedBy  string `json:"recordedBy"`
+	TxID        string `json:"txId"`
+}
+
+// appliesTo reports whether the directive applies to an aircraft type
+func (d Directive) appliesTo(aircraftType string) bool {
+	return matchesAircraftType(d.AircraftTypes, aircraftType)
+}
+
+// matchesAircraftType reports whether an aircraft type is one of types,
+// where a type ending in * matches every type starting with the rest
+func matchesAircraftType(types []string, aircraftType string) bool {
+	aircraftType = normalizeAircraftType(aircraftType)
+	for _, applicable := range types {
+		applicable = normalizeAircraftType(applicable)
+		if prefix, ok := strings.CutSuffix(applicable, "*"); ok {
+			if strings.HasPrefix(aircraftType, prefix) {
+				return true
+			}
+		} else if applicable == aircraftType {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeAircraftType(aircraftType string) string {
+	return strings.ToUpper(strings.TrimSpace(aircraftType))
+}
+
+// PublishDirective stores an AD or SB given as JSON, replacing an earlier
+// revision with the same ID. Only the regulator may publish directives.
+func (s *SimpleChaincode) PublishDirective(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	if err := requireRegulator(stub); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	var directive Directive
+	if err := json.Unmarshal([]byte(args[0]), &directive); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to unmarshal directive: %s", err))
+	}
+	switch {
+	case directive.ID == "":
+		return shim.Error("Directive ID is required")
+	case directive.Kind != DirectiveAD && directive.Kind != DirectiveSB:
+		return shim.Error(fmt.Sprintf("Directive kind must be %s or %s, got %s", DirectiveAD, DirectiveSB, directive.Kind))
+	case len(directive.AircraftTypes) == 0:
+		return shim.Error("Directive must list the aircraft types it applies to")
+	case directive.ComplianceMethod == "":
+		return shim.Error("Directive compliance method is required")
+	}
+	if _, err := time.Parse(dueDateLayout, directive.EffectiveDate); err != nil {
+		return shim.Error(fmt.Sprintf("Effective date must be YYYY-MM-DD, got %s", directive.EffectiveDate))
+	}
+
+	mspID, err := submitterMSPID(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	publishedAt, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read transaction time: %s", err))
+	}
+	directive.PublishedBy = mspID
+	directive.PublishedAt = time.Unix(publishedAt.Seconds, int64(publishedAt.Nanos)).UTC()
+
+	key, err := stub.CreateCompositeKey(directiveKeyType, []string{directive.ID})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create directive key: %s", err))
+	}
+	directiveJSON, err := json.Marshal(directive)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal directive: %s", err))
+	}
+	if err := stub.PutState(key, directiveJSON); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to store directive: %s", err))
+	}
+
+	return shim.Success(directiveJSON)
+}
+
+// GetDirective returns a published directive
+func (s *SimpleChaincode) GetDirective(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	key, err := stub.CreateCompositeKey(directiveKeyType, []string{args[0]})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create directive key: %s", err))
+	}
+	directiveJSON, err := stub.GetState(key)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read directive: %s", err))
+	}
+	if directiveJSON == nil {
+		return shim.Error(fmt.Sprintf("Directive %s does not exist", args[0]))
+	}
+
+	return shim.Success(directiveJSON)
+}
+
+// GetAllDirectives returns the directive catalog
+func (s *SimpleChaincode) GetAllDirectives(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 0 {
+		return shim.Error("Incorrect number of arguments. Expecting 0")
+	}
+
+	directives, err := readDirectives(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	directivesJSON, err := json.Marshal(directives)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal directives: %s", err))
+	}
+
+	return shim.Success(directivesJSON)
+}
+
+func readDirectives(stub shim.ChaincodeStubInterface) ([]Directive, error) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(directiveKeyType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read directives: %s", err)
+	}
+	defer resultsIterator.Close()
+
+	directives := []Directive{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Error iterating directives: %s", err)
+		}
+		var directive Directive
+		if err := json.Unmarshal(result.Value, &directive); err != nil {
+			return nil, fmt.Errorf("Failed to unmarshal directive: %s", err)
+		}
+		directives = append(directives, directive)
+	}
+	return directives, nil
+}
+
+// RecordDirectiveCompliance records that a registered aircraft complied with
+// a directive for its type. Arguments: aircraft ID, directive ID, compliance
+// date, method and optionally the asset reporting the work on the aircraft.
+// Only the operator's organization, or the one that filed the asset, may
+// record it.
+func (s *SimpleChaincode) RecordDirectiveCompliance(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 4 && len(args) != 5 {
+		return shim.Error("Incorrect number of arguments. Expecting 4 or 5")
+	}
+
+	record := DirectiveCompliance{
+		AircraftID:  args[0],
+		DirectiveID: args[1],
+		Date:        args[2],
+		Method:      args[3],
+		TxID:        stub.GetTxID(),
+	}
+	if len(args) == 5 {
+		record.AssetID = args[4]
+	}
+	if record.AircraftID == "" {
+		return shim.Error("Aircraft ID is required")
+	}
+	if _, err := time.Parse(dueDateLayout, record.Date); err != nil {
+		return shim.Error(fmt.Sprintf("Compliance date must be YYYY-MM-DD, got %s", record.Date))
+	}
+
+	response := s.GetDirective(stub, []string{record.DirectiveID})
+	if response.Status != shim.OK {
+		return response
+	}
+	var directive Directive
+	if err := json.Unmarshal(response.Payload, &directive); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to unmarshal directive: %s", err))
+	}
+
+	aircraft, err := readAircraft(stub, record.AircraftID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if aircraft == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s does not exist", record.AircraftID))
+	}
+	record.AircraftID = aircraft.Registration
+	if !directive.appliesTo(aircraft.Type) {
+		return shim.Error(fmt.Sprintf("Directive %s does not apply to %s, a %s", directive.ID, aircraft.Registration, aircraft.Type))
+	}
+	operator, err := readAirline(stub, aircraft.Operator)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if operator == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", aircraft.Operator))
+	}
+
+	record.RecordedBy, err = submitterMSPID(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	allowed := record.RecordedBy == operator.MSPID
+	if record.AssetID != "" {
+		assetJSON, err := stub.GetState(record.AssetID)
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Failed to read asset: %s", err))
+		}
+		if assetJSON == nil {
+			return shim.Error(fmt.Sprintf("Asset %s does not exist", record.AssetID))
+		}
+		var asset Asset
+		if err := json.Unmarshal(assetJSON, &asset); err != nil {
+			return shim.Error(fmt.Sprintf("Failed to unmarshal asset: %s", err))
+		}
+		if asset.AircraftID != aircraft.Registration {
+			return shim.Error(fmt.Sprintf("Asset %s reports on %s, not %s", asset.ID, asset.AircraftID, aircraft.Registration))
+		}
+		allowed = allowed || record.RecordedBy == asset.InspectingOrg
+	}
+	if !allowed {
+		return shim.Error(fmt.Sprintf("Access denied: only %s, the operator of %s, or the organization that inspected it may record its compliance, not %s", operator.MSPID, aircraft.Registration, record.RecordedBy))
+	}
+
+	key, err := stub.CreateCompositeKey(directiveComplianceKeyType, []string{record.AircraftID, record.DirectiveID})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create compliance key: %s", err))
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal compliance record: %s", err))
+	}
+	if err := stub.PutState(key, recordJSON); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to store compliance record: %s", err))
+	}
+
+	return shim.Success(recordJSON)
+}
+
+// GetDirectiveCompliance returns the directives an aircraft complied with
+func (s *SimpleChaincode) GetDirectiveCompliance(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	// Records are keyed by the registration as registered, so pk-gfa finds
+	// those of PK-GFA
+	records, err := readDirectiveCompliance(stub, normalizeRegistration(args[0]))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	recordsJSON, err := json.Marshal(records)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal compliance records: %s", err))
+	}
+
+	return shim.Success(recordsJSON)
+}
+
+func readDirectiveCompliance(stub shim.ChaincodeStubInterface, aircraftID string) ([]DirectiveCompliance, error) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(directiveComplianceKeyType, []string{aircraftID})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read compliance records: %s", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []DirectiveCompliance{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Error iterating compliance records: %s", err)
+		}
+		var record DirectiveCompliance
+		if err := json.Unmarshal(result.Value, &record); err != nil {
+			return nil, fmt.Errorf("Failed to unmarshal compliance record: %s", err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// GetOutstandingDirectives returns the directives in effect for an
+// aircraft's type, as registered, that the aircraft has not complied with
+func (s *SimpleChaincode) GetOutstandingDirectives(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	aircraft, err := readAircraft(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if aircraft == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s does not exist", args[0]))
+	}
+	aircraftID, aircraftType := aircraft.Registration, aircraft.Type
+
+	today, err := txDate(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	directives, err := readDirectives(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	records, err := readDirectiveCompliance(stub, aircraftID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	complied := make(map[string]bool, len(records))
+	for _, record := range records {
+		complied[record.DirectiveID] = true
+	}
+
+	outstanding := []Directive{}
+	for _, directive := range directives {
+		if directive.appliesTo(aircraftType) && directive.EffectiveDate <= today && !complied[directive.ID] {
+			outstanding = append(outstanding, directive)
+		}
+	}
+	sort.Slice(outstanding, func(i, j int) bool {
+		if outstanding[i].EffectiveDate != outstanding[j].EffectiveDate {
+			return outstanding[i].EffectiveDate < outstanding[j].EffectiveDate
+		}
+		return outstanding[i].ID < outstanding[j].ID
+	})
+
+	outstandingJSON, err := json.Marshal(outstanding)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal directives: %s", err))
+	}
+
+	return shim.Success(outstandingJSON)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// setAssetEndorsement sets the key-level endorsement policy of an asset so
+// any later change to it, such as its compliance, must be endorsed by a peer
+// of the regulator and of the inspecting organization. The policy replaces
+// the chaincode-level one for that key only.
+func setAssetEndorsement(stub shim.ChaincodeStubInterface, id, inspectingOrg string) error {
+	policy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return fmt.Errorf("Failed to create endorsement policy: %s", err)
+	}
+	if err := policy.AddOrgs(statebased.RoleTypePeer, regulatorMSPID, inspectingOrg); err != nil {
+		return fmt.Errorf("Failed to add endorsing organizations: %s", err)
+	}
+	policyBytes, err := policy.Policy()
+	if err != nil {
+		return fmt.Errorf("Failed to marshal endorsement policy: %s", err)
+	}
+	if err := stub.SetStateValidationParameter(id, policyBytes); err != nil {
+		return fmt.Errorf("Failed to set endorsement policy: %s", err)
+	}
+	return nil
+}
+
+// ensureAssetEndorsement sets the endorsement policy of an asset stored
+// before assets had one. The organization updating it becomes its
+// inspecting organization unless the asset names one.
+func ensureAssetEndorsement(stub shim.ChaincodeStubInterface, asset *Asset) error {
+	policyBytes, err := stub.GetStateValidationParameter(asset.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to read endorsement policy: %s", err)
+	}
+	if len(policyBytes) > 0 {
+		return nil
+	}
+	if asset.InspectingOrg == "" {
+		if asset.InspectingOrg, err = submitterMSPID(stub); err != nil {
+			return err
+		}
+	}
+	return setAssetEndorsement(stub, asset.ID, asset.InspectingOrg)
+}
+
+// GetEndorsingOrgs returns the organizations that must endorse changes to
+// an asset, or an empty list when the asset has no key-level policy and the
+// chaincode-level policy applies
+func (s *SimpleChaincode) GetEndorsingOrgs(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	exists, err := s.AssetExists(stub, args[0])
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Error checking asset existence: %s", err))
+	}
+	if !exists {
+		return shim.Error(fmt.Sprintf("Asset %s does not exist", args[0]))
+	}
+
+	orgs := []string{}
+	policyBytes, err := stub.GetStateValidationParameter(args[0])
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read endorsement policy: %s", err))
+	}
+	if len(policyBytes) > 0 {
+		policy, err := statebased.NewStateEP(policyBytes)
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Failed to parse endorsement policy: %s", err))
+		}
+		orgs = policy.ListOrgs()
+		sort.Strings(orgs)
+	}
+
+	orgsJSON, err := json.Marshal(orgs)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal organizations: %s", err))
+	}
+
+	return shim.Success(orgsJSON)
+}
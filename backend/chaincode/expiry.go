@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// StatusExpired marks an asset whose due date passed without a new
+// inspection
+const StatusExpired = "Expired"
+
+// dueDateLayout is the format of due dates, like report dates
+const dueDateLayout = "2006-01-02"
+
+// validateDueDate checks that a due date is a YYYY-MM-DD date
+func validateDueDate(dueDate string) error {
+	if _, err := time.Parse(dueDateLayout, dueDate); err != nil {
+		return fmt.Errorf("Due date must be YYYY-MM-DD, got %s", dueDate)
+	}
+	return nil
+}
+
+// txDate returns the date of the transaction in UTC. Endorsers agree on it,
+// unlike on their own clocks.
+func txDate(stub shim.ChaincodeStubInterface) (string, error) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("Failed to read transaction time: %s", err)
+	}
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC().Format(dueDateLayout), nil
+}
+
+// pastDue reports whether the asset's due date is before today. An asset is
+// compliant up to and including its due date.
+func pastDue(asset Asset, today string) bool {
+	return asset.DueDate != "" && asset.DueDate < today
+}
+
+// requireNotPastDue refuses to report an asset as compliant past its due date
+func requireNotPastDue(stub shim.ChaincodeStubInterface, asset Asset) error {
+	if !asset.Compliance || asset.DueDate == "" {
+		return nil
+	}
+	today, err := txDate(stub)
+	if err != nil {
+		return err
+	}
+	if pastDue(asset, today) {
+		return fmt.Errorf("Asset %s cannot be compliant, it was due on %s", asset.ID, asset.DueDate)
+	}
+	return nil
+}
+
+// MarkExpired marks an asset past its due date as Expired and no longer
+// compliant, and emits an AssetExpired event with the asset
+func (s *SimpleChaincode) MarkExpired(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	id := args[0]
+	assetJSON, err := stub.GetState(id)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read asset: %s", err))
+	}
+	if assetJSON == nil {
+		return shim.Error(fmt.Sprintf("Asset %s does not exist", id))
+	}
+
+	var asset Asset
+	err = json.Unmarshal(assetJSON, &asset)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to unmarshal asset: %s", err))
+	}
+	if asset.Status == StatusExpired {
+		return shim.Error(fmt.Sprintf("Asset %s is already expired", id))
+	}
+
+	today, err := txDate(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !pastDue(asset, today) {
+		return shim.Error(fmt.Sprintf("Asset %s is not past its due date", id))
+	}
+	if err := ensureAssetEndorsement(stub, &asset); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	asset.Status = StatusExpired
+	asset.Compliance = false
+	assetJSON, err = json.Marshal(asset)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal updated asset: %s", err))
+	}
+
+	err = stub.PutState(id, assetJSON)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to store updated asset: %s", err))
+	}
+	if err := stub.SetEvent("AssetExpired", assetJSON); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to set event: %s", err))
+	}
+
+	return shim.Success(assetJSON)
+}
+
+// GetDueAssets returns the assets due on or before the given date,
+// including expired ones
+func (s *SimpleChaincode) GetDueAssets(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	if err := validateDueDate(args[0]); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	resultsIterator, err := stub.GetStateByRange("", "")
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read assets: %s", err))
+	}
+	defer resultsIterator.Close()
+
+	assets := []Asset{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Error iterating assets: %s", err))
+		}
+
+		var asset Asset
+		if err := json.Unmarshal(result.Value, &asset); err != nil {
+			return shim.Error(fmt.Sprintf("Failed to unmarshal asset: %s", err))
+		}
+		if asset.DueDate != "" && asset.DueDate <= args[0] {
+			assets = append(assets, asset)
+		}
+	}
+
+	assetsJSON, err := json.Marshal(assets)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal assets: %s", err))
+	}
+
+	return shim.Success(assetsJSON)
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	inspectorKeyType         = "inspector"
+	inspectorIdentityKeyType = "inspectorIdentity"
+)
+
+// Inspector is a licensed inspector, keyed by license number. MSPID and
+// Identity, the x509::<subject>::<issuer> ID of their certificate, link the
+// license to the identity that submits their reports. Instead of Identity a
+// PEM Certificate may be given when registering, it is not stored.
+type Inspector struct {
+	LicenseNumber    string           `json:"licenseNumber"`
+	Name             string           `json:"name"`
+	IssuingAuthority string           `json:"issuingAuthority"`
+	Ratings          InspectorRatings `json:"ratings"`
+	ValidFrom        string           `json:"validFrom"`
+	ValidUntil       string           `json:"validUntil"`
+	MSPID            string           `json:"mspId"`
+	Identity         string           `json:"identity"`
+	Certificate      string           `json:"certificate,omitempty"`
+}
+
+// InspectorRatings are the aircraft types, such as 737-* for all 737
+// variants, and the checks, such as A-check, an inspector may sign off
+type InspectorRatings struct {
+	AircraftTypes []string `json:"aircraftTypes"`
+	CheckTypes    []string `json:"checkTypes"`
+}
+
+// ratedForCheck reports whether the ratings include a check type, ignoring
+// case
+func (r InspectorRatings) ratedForCheck(checkType string) bool {
+	for _, rated := range r.CheckTypes {
+		if strings.EqualFold(rated, checkType) {
+			return true
+		}
+	}
+	return false
+}
+
+// current reports whether the license is valid on the given date
+func (i Inspector) current(today string) bool {
+	return i.ValidFrom <= today && today <= i.ValidUntil
+}
+
+func (i *Inspector) validate() error {
+	i.LicenseNumber = strings.TrimSpace(i.LicenseNumber)
+	switch {
+	case i.LicenseNumber == "":
+		return fmt.Errorf("Inspector license number is required")
+	case i.Name == "":
+		return fmt.Errorf("Inspector name is required")
+	case i.IssuingAuthority == "":
+		return fmt.Errorf("Inspector issuing authority is required")
+	case len(i.Ratings.AircraftTypes) == 0:
+		return fmt.Errorf("Inspector must be rated for at least one aircraft type")
+	case len(i.Ratings.CheckTypes) == 0:
+		return fmt.Errorf("Inspector must be rated for at least one check type")
+	case i.MSPID == "":
+		return fmt.Errorf("Inspector MSP ID is required")
+	}
+	for n, checkType := range i.Ratings.CheckTypes {
+		i.Ratings.CheckTypes[n] = strings.TrimSpace(checkType)
+		if i.Ratings.CheckTypes[n] == "" {
+			return fmt.Errorf("Inspector check types must not be empty")
+		}
+	}
+	for _, date := range []string{i.ValidFrom, i.ValidUntil} {
+		if err := validateDueDate(date); err != nil {
+			return fmt.Errorf("License validity must be YYYY-MM-DD, got %s", date)
+		}
+	}
+	if i.ValidUntil < i.ValidFrom {
+		return fmt.Errorf("License is valid until %s, before it is valid from %s", i.ValidUntil, i.ValidFrom)
+	}
+
+	if i.Certificate != "" {
+		identity, err := certificateIdentity(i.MSPID, i.Certificate)
+		if err != nil {
+			return err
+		}
+		i.Identity, i.Certificate = identity, ""
+	}
+	if !strings.HasPrefix(i.Identity, "x509::") {
+		return fmt.Errorf("Inspector identity must be an x509::<subject>::<issuer> ID or given as a certificate")
+	}
+	return nil
+}
+
+// inspectorIdentityIndex maps a certificate identity to its license
+type inspectorIdentityIndex struct {
+	LicenseNumber string `json:"licenseNumber"`
+}
+
+// creatorBytes serves an identity to cid as if it submitted a transaction
+type creatorBytes []byte
+
+func (c creatorBytes) GetCreator() ([]byte, error) {
+	return c, nil
+}
+
+// certificateIdentity returns the ID cid gives the holder of a certificate
+func certificateIdentity(mspID, certificatePEM string) (string, error) {
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: []byte(certificatePEM)})
+	if err != nil {
+		return "", fmt.Errorf("Failed to marshal identity: %s", err)
+	}
+	return clientIdentity(creatorBytes(creator))
+}
+
+// clientIdentity returns the x509::<subject>::<issuer> ID of a submitter
+func clientIdentity(stub cid.ChaincodeStubInterface) (string, error) {
+	encoded, err := cid.GetID(stub)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read submitter identity: %s", err)
+	}
+	id, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("Failed to decode submitter identity: %s", err)
+	}
+	return string(id), nil
+}
+
+// RegisterInspector registers a licensed inspector given as JSON. Only the
+// regulator may license inspectors.
+func (s *SimpleChaincode) RegisterInspector(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	return s.putInspector(stub, args, false)
+}
+
+// UpdateInspector replaces a licensed inspector with the one given as JSON,
+// e.g. to renew, restrict or revoke the license by changing its validity or
+// ratings. Only the regulator may update inspectors.
+func (s *SimpleChaincode) UpdateInspector(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	return s.putInspector(stub, args, true)
+}
+
+func (s *SimpleChaincode) putInspector(stub shim.ChaincodeStubInterface, args []string, update bool) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	if err := requireRegulator(stub); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	var inspector Inspector
+	if err := json.Unmarshal([]byte(args[0]), &inspector); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to unmarshal inspector: %s", err))
+	}
+	if err := inspector.validate(); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	existing, err := readInspector(stub, inspector.LicenseNumber)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if update && existing == nil {
+		return shim.Error(fmt.Sprintf("Inspector %s does not exist", inspector.LicenseNumber))
+	}
+	if !update && existing != nil {
+		return shim.Error(fmt.Sprintf("Inspector %s already exists", inspector.LicenseNumber))
+	}
+
+	licensed, err := inspectorByIdentity(stub, inspector.MSPID, inspector.Identity)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if licensed != "" && licensed != inspector.LicenseNumber {
+		return shim.Error(fmt.Sprintf("Identity %s already holds license %s", inspector.Identity, licensed))
+	}
+	if existing != nil && (existing.MSPID != inspector.MSPID || existing.Identity != inspector.Identity) {
+		key, err := stub.CreateCompositeKey(inspectorIdentityKeyType, []string{existing.MSPID, existing.Identity})
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Failed to create inspector identity key: %s", err))
+		}
+		if err := stub.DelState(key); err != nil {
+			return shim.Error(fmt.Sprintf("Failed to delete inspector identity: %s", err))
+		}
+	}
+	key, err := stub.CreateCompositeKey(inspectorIdentityKeyType, []string{inspector.MSPID, inspector.Identity})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create inspector identity key: %s", err))
+	}
+	indexJSON, err := json.Marshal(inspectorIdentityIndex{LicenseNumber: inspector.LicenseNumber})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal inspector identity: %s", err))
+	}
+	if err := stub.PutState(key, indexJSON); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to store inspector identity: %s", err))
+	}
+
+	return putMasterData(stub, inspectorKeyType, inspector.LicenseNumber, inspector)
+}
+
+// ReadInspector returns a licensed inspector by license number
+func (s *SimpleChaincode) ReadInspector(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	inspector, err := readInspector(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if inspector == nil {
+		return shim.Error(fmt.Sprintf("Inspector %s does not exist", args[0]))
+	}
+	inspectorJSON, err := json.Marshal(inspector)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal inspector: %s", err))
+	}
+
+	return shim.Success(inspectorJSON)
+}
+
+// GetAllInspectors returns every licensed inspector
+func (s *SimpleChaincode) GetAllInspectors(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 0 {
+		return shim.Error("Incorrect number of arguments. Expecting 0")
+	}
+
+	inspectors := []Inspector{}
+	err := readAllMasterData(stub, inspectorKeyType, func(value []byte) error {
+		var inspector Inspector
+		if err := json.Unmarshal(value, &inspector); err != nil {
+			return err
+		}
+		inspectors = append(inspectors, inspector)
+		return nil
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	inspectorsJSON, err := json.Marshal(inspectors)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal inspectors: %s", err))
+	}
+
+	return shim.Success(inspectorsJSON)
+}
+
+// GetInspectorHistory returns every revision of an inspector's license
+func (s *SimpleChaincode) GetInspectorHistory(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	return masterDataHistory(stub, inspectorKeyType, strings.TrimSpace(args[0]))
+}
+
+// requireLicensedInspector returns the license of the submitter, failing
+// unless they are an inspector licensed today and rated for the aircraft
+// type and the check
+func requireLicensedInspector(stub shim.ChaincodeStubInterface, aircraftType, checkType string) (*Inspector, error) {
+	mspID, err := submitterMSPID(stub)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := clientIdentity(stub)
+	if err != nil {
+		return nil, err
+	}
+	licenseNumber, err := inspectorByIdentity(stub, mspID, identity)
+	if err != nil {
+		return nil, err
+	}
+	if licenseNumber == "" {
+		return nil, fmt.Errorf("Access denied: %s of %s is not a licensed inspector", identity, mspID)
+	}
+	inspector, err := readInspector(stub, licenseNumber)
+	if err != nil {
+		return nil, err
+	}
+	if inspector == nil {
+		return nil, fmt.Errorf("Inspector %s does not exist", licenseNumber)
+	}
+
+	today, err := txDate(stub)
+	if err != nil {
+		return nil, err
+	}
+	if !inspector.current(today) {
+		return nil, fmt.Errorf("Access denied: license %s is valid from %s until %s", inspector.LicenseNumber, inspector.ValidFrom, inspector.ValidUntil)
+	}
+	if !matchesAircraftType(inspector.Ratings.AircraftTypes, aircraftType) {
+		return nil, fmt.Errorf("Access denied: license %s is not rated for %s", inspector.LicenseNumber, aircraftType)
+	}
+	if !inspector.Ratings.ratedForCheck(checkType) {
+		return nil, fmt.Errorf("Access denied: license %s is not rated for %s", inspector.LicenseNumber, checkType)
+	}
+	return inspector, nil
+}
+
+func readInspector(stub shim.ChaincodeStubInterface, licenseNumber string) (*Inspector, error) {
+	var inspector Inspector
+	found, err := readMasterData(stub, inspectorKeyType, strings.TrimSpace(licenseNumber), &inspector)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &inspector, nil
+}
+
+// inspectorByIdentity returns the license number held by an identity, or ""
+func inspectorByIdentity(stub shim.ChaincodeStubInterface, mspID, identity string) (string, error) {
+	key, err := stub.CreateCompositeKey(inspectorIdentityKeyType, []string{mspID, identity})
+	if err != nil {
+		return "", fmt.Errorf("Failed to create inspector identity key: %s", err)
+	}
+	indexJSON, err := stub.GetState(key)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read inspector identity: %s", err)
+	}
+	if indexJSON == nil {
+		return "", nil
+	}
+	var index inspectorIdentityIndex
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return "", fmt.Errorf("Failed to unmarshal inspector identity: %s", err)
+	}
+	return index.LicenseNumber, nil
+}
@@ -0,0 +1,547 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	airlineKeyType  = "airline"
+	aircraftKeyType = "aircraft"
+)
+
+var (
+	icaoAirlineCode = regexp.MustCompile(`^[A-Z]{3}$`)
+	iataAirlineCode = regexp.MustCompile(`^[A-Z0-9]{2}$`)
+)
+
+// Airline is an air operator, keyed by its ICAO code. MSPID binds it to the
+// organization that acts for it on the network.
+type Airline struct {
+	ICAO      string `json:"icao"`
+	IATA      string `json:"iata,omitempty"`
+	Name      string `json:"name"`
+	AOCNumber string `json:"aocNumber"`
+	MSPID     string `json:"mspId"`
+}
+
+// Aircraft is an aircraft on the register, keyed by its registration.
+// Operator is the ICAO code of the airline operating it, OwnershipChain
+// every operator it had, oldest first. Only TransferAircraft changes them.
+type Aircraft struct {
+	Registration   string           `json:"registration"`
+	MSN            string           `json:"msn"`
+	Type           string           `json:"type"`
+	Operator       string           `json:"operator"`
+	OwnershipChain []OperatorRecord `json:"ownershipChain,omitempty"`
+}
+
+// OperatorRecord is a period an aircraft was operated by an airline
+type OperatorRecord struct {
+	Operator string `json:"operator"`
+	Since    string `json:"since"`
+	TxID     string `json:"txId"`
+}
+
+// MasterDataHistory is one revision of an airline or aircraft
+type MasterDataHistory struct {
+	TxID      string          `json:"txId"`
+	Timestamp time.Time       `json:"timestamp"`
+	IsDelete  bool            `json:"isDelete"`
+	Value     json.RawMessage `json:"value,omitempty"`
+}
+
+func (a *Airline) validate() error {
+	a.ICAO = strings.ToUpper(strings.TrimSpace(a.ICAO))
+	a.IATA = strings.ToUpper(strings.TrimSpace(a.IATA))
+	a.Name = strings.TrimSpace(a.Name)
+	switch {
+	case !icaoAirlineCode.MatchString(a.ICAO):
+		return fmt.Errorf("Airline ICAO code must be 3 letters, got %s", a.ICAO)
+	case a.IATA != "" && !iataAirlineCode.MatchString(a.IATA):
+		return fmt.Errorf("Airline IATA code must be 2 letters or digits, got %s", a.IATA)
+	case a.Name == "":
+		return fmt.Errorf("Airline name is required")
+	case a.AOCNumber == "":
+		return fmt.Errorf("Airline AOC number is required")
+	case a.MSPID == "":
+		return fmt.Errorf("Airline MSP ID is required")
+	}
+	return nil
+}
+
+func (a *Aircraft) validate() error {
+	a.Registration = normalizeRegistration(a.Registration)
+	a.Operator = strings.ToUpper(strings.TrimSpace(a.Operator))
+	switch {
+	case a.Registration == "":
+		return fmt.Errorf("Aircraft registration is required")
+	case a.MSN == "":
+		return fmt.Errorf("Aircraft MSN is required")
+	case a.Type == "":
+		return fmt.Errorf("Aircraft type is required")
+	case a.Operator == "":
+		return fmt.Errorf("Aircraft operator is required")
+	}
+	return nil
+}
+
+func normalizeRegistration(registration string) string {
+	return strings.ToUpper(strings.TrimSpace(registration))
+}
+
+// normalizeAirlineName folds case and spacing so spellings of a name that
+// only differ in those match
+func normalizeAirlineName(name string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(name)), " ")
+}
+
+// CreateAirline registers an airline given as JSON. Only the regulator, who
+// issues the AOC, may register airlines.
+func (s *SimpleChaincode) CreateAirline(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	return s.putAirline(stub, args, false)
+}
+
+// UpdateAirline replaces a registered airline with the one given as JSON.
+// Only the regulator may update airlines.
+func (s *SimpleChaincode) UpdateAirline(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	return s.putAirline(stub, args, true)
+}
+
+func (s *SimpleChaincode) putAirline(stub shim.ChaincodeStubInterface, args []string, update bool) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	if err := requireRegulator(stub); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	var airline Airline
+	if err := json.Unmarshal([]byte(args[0]), &airline); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to unmarshal airline: %s", err))
+	}
+	if err := airline.validate(); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	existing, err := readAirline(stub, airline.ICAO)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if update && existing == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", airline.ICAO))
+	}
+	if !update && existing != nil {
+		return shim.Error(fmt.Sprintf("Airline %s already exists", airline.ICAO))
+	}
+
+	return putMasterData(stub, airlineKeyType, airline.ICAO, airline)
+}
+
+// ReadAirline returns a registered airline by ICAO code
+func (s *SimpleChaincode) ReadAirline(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	airline, err := readAirline(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if airline == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", args[0]))
+	}
+	airlineJSON, err := json.Marshal(airline)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal airline: %s", err))
+	}
+
+	return shim.Success(airlineJSON)
+}
+
+// DeleteAirline removes an airline that no longer operates any aircraft.
+// Only the regulator may delete airlines.
+func (s *SimpleChaincode) DeleteAirline(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	if err := requireRegulator(stub); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	airline, err := readAirline(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if airline == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", args[0]))
+	}
+	fleet, err := readAllAircraft(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	for _, aircraft := range fleet {
+		if aircraft.Operator == airline.ICAO {
+			return shim.Error(fmt.Sprintf("Airline %s still operates %s", airline.ICAO, aircraft.Registration))
+		}
+	}
+
+	return deleteMasterData(stub, airlineKeyType, airline.ICAO)
+}
+
+// GetAllAirlines returns every registered airline
+func (s *SimpleChaincode) GetAllAirlines(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 0 {
+		return shim.Error("Incorrect number of arguments. Expecting 0")
+	}
+
+	airlines, err := readAllAirlines(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	airlinesJSON, err := json.Marshal(airlines)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal airlines: %s", err))
+	}
+
+	return shim.Success(airlinesJSON)
+}
+
+// GetAirlineHistory returns every revision of an airline
+func (s *SimpleChaincode) GetAirlineHistory(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	return masterDataHistory(stub, airlineKeyType, strings.ToUpper(args[0]))
+}
+
+// CreateAircraft registers an aircraft given as JSON. The regulator or the
+// organization of its operator may register aircraft.
+func (s *SimpleChaincode) CreateAircraft(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	return s.putAircraft(stub, args, false)
+}
+
+// UpdateAircraft replaces a registered aircraft with the one given as JSON.
+// The regulator or the organization of its operator may update an aircraft,
+// but not change its operator.
+func (s *SimpleChaincode) UpdateAircraft(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	return s.putAircraft(stub, args, true)
+}
+
+func (s *SimpleChaincode) putAircraft(stub shim.ChaincodeStubInterface, args []string, update bool) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	var aircraft Aircraft
+	if err := json.Unmarshal([]byte(args[0]), &aircraft); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to unmarshal aircraft: %s", err))
+	}
+	if err := aircraft.validate(); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	existing, err := readAircraft(stub, aircraft.Registration)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if update && existing == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s does not exist", aircraft.Registration))
+	}
+	if !update && existing != nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s already exists", aircraft.Registration))
+	}
+	if update && existing.Operator != aircraft.Operator {
+		return shim.Error(fmt.Sprintf("Aircraft %s is operated by %s, transfer it to change its operator", aircraft.Registration, existing.Operator))
+	}
+
+	operator, err := readAirline(stub, aircraft.Operator)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if operator == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", aircraft.Operator))
+	}
+	if err := requireRegulatorOr(stub, operator.MSPID); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if update {
+		aircraft.OwnershipChain = existing.OwnershipChain
+	} else {
+		since, err := txDate(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		aircraft.OwnershipChain = []OperatorRecord{{Operator: aircraft.Operator, Since: since, TxID: stub.GetTxID()}}
+	}
+
+	return putMasterData(stub, aircraftKeyType, aircraft.Registration, aircraft)
+}
+
+// ReadAircraft returns a registered aircraft by registration
+func (s *SimpleChaincode) ReadAircraft(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	aircraft, err := readAircraft(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if aircraft == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s does not exist", args[0]))
+	}
+	aircraftJSON, err := json.Marshal(aircraft)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal aircraft: %s", err))
+	}
+
+	return shim.Success(aircraftJSON)
+}
+
+// DeleteAircraft removes an aircraft from the register. Only the regulator
+// may delete aircraft.
+func (s *SimpleChaincode) DeleteAircraft(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	if err := requireRegulator(stub); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	aircraft, err := readAircraft(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if aircraft == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s does not exist", args[0]))
+	}
+	transfer, err := readTransfer(stub, aircraft.Registration)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if transfer != nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s has a transfer to %s pending", aircraft.Registration, transfer.To))
+	}
+
+	return deleteMasterData(stub, aircraftKeyType, aircraft.Registration)
+}
+
+// GetAllAircraft returns every registered aircraft
+func (s *SimpleChaincode) GetAllAircraft(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 0 {
+		return shim.Error("Incorrect number of arguments. Expecting 0")
+	}
+
+	fleet, err := readAllAircraft(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fleetJSON, err := json.Marshal(fleet)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal aircraft: %s", err))
+	}
+
+	return shim.Success(fleetJSON)
+}
+
+// GetAircraftHistory returns every revision of an aircraft
+func (s *SimpleChaincode) GetAircraftHistory(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+	return masterDataHistory(stub, aircraftKeyType, normalizeRegistration(args[0]))
+}
+
+// requireRegulatorOr fails unless the transaction was submitted by the
+// regulator or by the given organization
+func requireRegulatorOr(stub shim.ChaincodeStubInterface, mspID string) error {
+	submitter, err := submitterMSPID(stub)
+	if err != nil {
+		return err
+	}
+	if submitter != regulatorMSPID && submitter != mspID {
+		return fmt.Errorf("Access denied: only the regulator (%s) or %s may do this, not %s", regulatorMSPID, mspID, submitter)
+	}
+	return nil
+}
+
+// resolveAssetReferences looks up the airline and aircraft a report is filed
+// under, by ICAO code and registration. The aircraft must be operated by the
+// airline.
+func resolveAssetReferences(stub shim.ChaincodeStubInterface, icao, registration string) (*Airline, *Aircraft, error) {
+	airline, err := readAirline(stub, icao)
+	if err != nil {
+		return nil, nil, err
+	}
+	if airline == nil {
+		return nil, nil, fmt.Errorf("Airline %s does not exist", icao)
+	}
+
+	aircraft, err := readAircraft(stub, registration)
+	if err != nil {
+		return nil, nil, err
+	}
+	if aircraft == nil {
+		return nil, nil, fmt.Errorf("Aircraft %s does not exist", registration)
+	}
+	if aircraft.Operator != airline.ICAO {
+		return nil, nil, fmt.Errorf("Aircraft %s is operated by %s, not %s", aircraft.Registration, aircraft.Operator, airline.ICAO)
+	}
+	return airline, aircraft, nil
+}
+
+func readAirline(stub shim.ChaincodeStubInterface, icao string) (*Airline, error) {
+	var airline Airline
+	found, err := readMasterData(stub, airlineKeyType, strings.ToUpper(strings.TrimSpace(icao)), &airline)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &airline, nil
+}
+
+func readAircraft(stub shim.ChaincodeStubInterface, registration string) (*Aircraft, error) {
+	var aircraft Aircraft
+	found, err := readMasterData(stub, aircraftKeyType, normalizeRegistration(registration), &aircraft)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &aircraft, nil
+}
+
+func readAllAirlines(stub shim.ChaincodeStubInterface) ([]Airline, error) {
+	airlines := []Airline{}
+	err := readAllMasterData(stub, airlineKeyType, func(value []byte) error {
+		var airline Airline
+		if err := json.Unmarshal(value, &airline); err != nil {
+			return err
+		}
+		airlines = append(airlines, airline)
+		return nil
+	})
+	return airlines, err
+}
+
+func readAllAircraft(stub shim.ChaincodeStubInterface) ([]Aircraft, error) {
+	fleet := []Aircraft{}
+	err := readAllMasterData(stub, aircraftKeyType, func(value []byte) error {
+		var aircraft Aircraft
+		if err := json.Unmarshal(value, &aircraft); err != nil {
+			return err
+		}
+		fleet = append(fleet, aircraft)
+		return nil
+	})
+	return fleet, err
+}
+
+func readMasterData(stub shim.ChaincodeStubInterface, keyType, id string, value interface{}) (bool, error) {
+	key, err := stub.CreateCompositeKey(keyType, []string{id})
+	if err != nil {
+		return false, fmt.Errorf("Failed to create %s key: %s", keyType, err)
+	}
+	valueJSON, err := stub.GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("Failed to read %s: %s", keyType, err)
+	}
+	if valueJSON == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(valueJSON, value); err != nil {
+		return false, fmt.Errorf("Failed to unmarshal %s: %s", keyType, err)
+	}
+	return true, nil
+}
+
+func readAllMasterData(stub shim.ChaincodeStubInterface, keyType string, add func([]byte) error) error {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(keyType, []string{})
+	if err != nil {
+		return fmt.Errorf("Failed to read %s records: %s", keyType, err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return fmt.Errorf("Error iterating %s records: %s", keyType, err)
+		}
+		if err := add(result.Value); err != nil {
+			return fmt.Errorf("Failed to unmarshal %s: %s", keyType, err)
+		}
+	}
+	return nil
+}
+
+func putMasterData(stub shim.ChaincodeStubInterface, keyType, id string, value interface{}) peer.Response {
+	key, err := stub.CreateCompositeKey(keyType, []string{id})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create %s key: %s", keyType, err))
+	}
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal %s: %s", keyType, err))
+	}
+	if err := stub.PutState(key, valueJSON); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to store %s: %s", keyType, err))
+	}
+
+	return shim.Success(valueJSON)
+}
+
+func deleteMasterData(stub shim.ChaincodeStubInterface, keyType, id string) peer.Response {
+	key, err := stub.CreateCompositeKey(keyType, []string{id})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create %s key: %s", keyType, err))
+	}
+	if err := stub.DelState(key); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to delete %s: %s", keyType, err))
+	}
+
+	return shim.Success([]byte(fmt.Sprintf("%s %s deleted successfully", keyType, id)))
+}
+
+// masterDataHistory returns every revision of an airline or aircraft,
+// including deletions
+func masterDataHistory(stub shim.ChaincodeStubInterface, keyType, id string) peer.Response {
+	key, err := stub.CreateCompositeKey(keyType, []string{id})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create %s key: %s", keyType, err))
+	}
+	resultsIterator, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to retrieve history: %s", err))
+	}
+	defer resultsIterator.Close()
+
+	history := []MasterDataHistory{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Error iterating history: %s", err))
+		}
+
+		entry := MasterDataHistory{
+			TxID:      response.TxId,
+			Timestamp: time.Unix(response.Timestamp.Seconds, int64(response.Timestamp.Nanos)),
+			IsDelete:  response.IsDelete,
+		}
+		if !response.IsDelete {
+			entry.Value = response.Value
+		}
+		history = append(history, entry)
+	}
+
+	historyJSON, err := json.Marshal(history)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal history: %s", err))
+	}
+
+	return shim.Success(historyJSON)
+}
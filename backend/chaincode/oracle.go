@@ -0,0 +1,254 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	oracleKeyType      = "oracle"
+	attestationKeyType = "attestation"
+
+	// attestationVersion prefixes the signed payload, see attestationPayload
+	attestationVersion = "aviation-compliance-oracle-attestation/v2"
+)
+
+// Oracle is an oracle identity whose attestations the chaincode accepts
+type Oracle struct {
+	ID           string    `json:"id"`
+	PublicKey    string    `json:"publicKey"`
+	RegisteredBy string    `json:"registeredBy"`
+	RegisteredAt time.Time `json:"registeredAt"`
+}
+
+// Attestation is an oracle's signed statement of where an asset's company
+// name came from
+type Attestation struct {
+	AssetID      string `json:"assetId"`
+	OracleID     string `json:"oracleId"`
+	Provider     string `json:"provider"`
+	Request      string `json:"request"`
+	Result       string `json:"result"`
+	ResponseHash string `json:"responseHash"`
+	FetchedAt    string `json:"fetchedAt"`
+	Signature    string `json:"signature"`
+}
+
+// attestationPayload is the message the oracle signs: the version and the
+// attested fields, one per line. The asset ID binds the attestation to one
+// report, so it cannot be replayed for another.
+func attestationPayload(a Attestation) []byte {
+	return []byte(strings.Join([]string{
+		attestationVersion, a.AssetID, a.OracleID, a.Provider, a.Request, a.Result, a.ResponseHash, a.FetchedAt,
+	}, "\n"))
+}
+
+// RegisterOracle stores the public key of an oracle. Only the regulator may
+// register or replace oracle keys.
+func (s *SimpleChaincode) RegisterOracle(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+
+	if err := requireRegulator(stub); err != nil {
+		return shim.Error(err.Error())
+	}
+	if _, err := parseOraclePublicKey(args[1]); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	mspID, err := submitterMSPID(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	registeredAt, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read transaction time: %s", err))
+	}
+
+	oracle := Oracle{
+		ID:           args[0],
+		PublicKey:    args[1],
+		RegisteredBy: mspID,
+		RegisteredAt: time.Unix(registeredAt.Seconds, int64(registeredAt.Nanos)).UTC(),
+	}
+	key, err := stub.CreateCompositeKey(oracleKeyType, []string{oracle.ID})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create oracle key: %s", err))
+	}
+	oracleJSON, err := json.Marshal(oracle)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal oracle: %s", err))
+	}
+	if err := stub.PutState(key, oracleJSON); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to store oracle: %s", err))
+	}
+
+	return shim.Success(oracleJSON)
+}
+
+// GetOracle returns a registered oracle
+func (s *SimpleChaincode) GetOracle(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	oracle, err := readOracle(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	oracleJSON, err := json.Marshal(oracle)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal oracle: %s", err))
+	}
+
+	return shim.Success(oracleJSON)
+}
+
+func readOracle(stub shim.ChaincodeStubInterface, id string) (*Oracle, error) {
+	key, err := stub.CreateCompositeKey(oracleKeyType, []string{id})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create oracle key: %s", err)
+	}
+	oracleJSON, err := stub.GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read oracle: %s", err)
+	}
+	if oracleJSON == nil {
+		return nil, fmt.Errorf("Oracle %s does not exist", id)
+	}
+
+	var oracle Oracle
+	if err := json.Unmarshal(oracleJSON, &oracle); err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal oracle: %s", err)
+	}
+	return &oracle, nil
+}
+
+func parseOraclePublicKey(publicKeyPEM string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("Invalid oracle public key: no PEM block found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid oracle public key: %s", err)
+	}
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("Invalid oracle public key: expected an ECDSA key")
+	}
+	return ecdsaKey, nil
+}
+
+// verifyAttestation checks the attestation's signature against the public
+// key registered for its oracle
+func verifyAttestation(stub shim.ChaincodeStubInterface, attestation Attestation) error {
+	oracle, err := readOracle(stub, attestation.OracleID)
+	if err != nil {
+		return err
+	}
+	publicKey, err := parseOraclePublicKey(oracle.PublicKey)
+	if err != nil {
+		return err
+	}
+	if _, err := time.Parse(time.RFC3339, attestation.FetchedAt); err != nil {
+		return fmt.Errorf("Invalid attestation fetch time: %s", err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(attestation.Signature)
+	if err != nil {
+		return fmt.Errorf("Invalid attestation signature: %s", err)
+	}
+	digest := sha256.Sum256(attestationPayload(attestation))
+	if !ecdsa.VerifyASN1(publicKey, digest[:], signature) {
+		return fmt.Errorf("Attestation signature does not match oracle %s", attestation.OracleID)
+	}
+	return nil
+}
+
+// CreateAttestedAsset creates a compliance report like CreateAsset, with
+// the oracle attestation of its company name as the 8th argument. The
+// attestation must be signed by a registered oracle, be about the report's
+// aircraft and attest the report's company name. The review, due date and
+// check type of CreateAsset follow as 9th to 11th arguments.
+func (s *SimpleChaincode) CreateAttestedAsset(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 11 {
+		return shim.Error("Incorrect number of arguments. Expecting 11")
+	}
+
+	var attestation Attestation
+	if err := json.Unmarshal([]byte(args[7]), &attestation); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to unmarshal attestation: %s", err))
+	}
+	if err := verifyAttestation(stub, attestation); err != nil {
+		return shim.Error(err.Error())
+	}
+	if attestation.AssetID != args[0] {
+		return shim.Error(fmt.Sprintf("Attestation is for asset %s, not %s", attestation.AssetID, args[0]))
+	}
+	if normalizeRegistration(attestation.Request) != normalizeRegistration(args[2]) {
+		return shim.Error(fmt.Sprintf("Attestation is for %s, not aircraft %s", attestation.Request, args[2]))
+	}
+	airline, err := readAirline(stub, args[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if airline == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", args[1]))
+	}
+	if normalizeAirlineName(attestation.Result) != normalizeAirlineName(airline.Name) {
+		return shim.Error(fmt.Sprintf("Attestation is for company %s, not %s", attestation.Result, airline.Name))
+	}
+
+	assetArgs := append(args[:7:7], args[8:]...)
+	asset, err := s.putNewAsset(stub, assetArgs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	key, err := stub.CreateCompositeKey(attestationKeyType, []string{asset.ID})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create attestation key: %s", err))
+	}
+	attestationJSON, err := json.Marshal(attestation)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal attestation: %s", err))
+	}
+	if err := stub.PutState(key, attestationJSON); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to store attestation: %s", err))
+	}
+
+	return shim.Success([]byte(fmt.Sprintf("Asset %s created successfully", asset.ID)))
+}
+
+// GetAttestation returns the oracle attestation stored with an asset
+func (s *SimpleChaincode) GetAttestation(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	key, err := stub.CreateCompositeKey(attestationKeyType, []string{args[0]})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create attestation key: %s", err))
+	}
+	attestationJSON, err := stub.GetState(key)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read attestation: %s", err))
+	}
+	if attestationJSON == nil {
+		return shim.Error(fmt.Sprintf("Attestation for asset %s does not exist", args[0]))
+	}
+
+	return shim.Success(attestationJSON)
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const transferKeyType = "aircraftTransfer"
+
+// AircraftTransfer is a change of operator the current operator proposed and
+// the new operator has yet to accept
+type AircraftTransfer struct {
+	Registration string    `json:"registration"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	ProposedBy   string    `json:"proposedBy"`
+	ProposedAt   time.Time `json:"proposedAt"`
+	TxID         string    `json:"txId"`
+}
+
+// TransferEvent is emitted when a transfer is proposed, accepted or
+// cancelled, so the regulator hears of every change of operator
+type TransferEvent struct {
+	Type     string           `json:"type"`
+	Transfer AircraftTransfer `json:"transfer"`
+	Aircraft *Aircraft        `json:"aircraft,omitempty"`
+}
+
+// TransferAircraft proposes moving an aircraft to a new operator. Arguments:
+// registration and the ICAO code of the new operator. Only the organization
+// of the current operator may propose it; the transfer takes effect once the
+// new operator accepts it with AcceptAircraftTransfer.
+func (s *SimpleChaincode) TransferAircraft(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 2 {
+		return shim.Error("Incorrect number of arguments. Expecting 2")
+	}
+
+	aircraft, err := readAircraft(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if aircraft == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s does not exist", args[0]))
+	}
+	pending, err := readTransfer(stub, aircraft.Registration)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if pending != nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s already has a transfer to %s pending", aircraft.Registration, pending.To))
+	}
+
+	from, err := readAirline(stub, aircraft.Operator)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if from == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", aircraft.Operator))
+	}
+	to, err := readAirline(stub, args[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if to == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", args[1]))
+	}
+	if to.ICAO == from.ICAO {
+		return shim.Error(fmt.Sprintf("Aircraft %s is already operated by %s", aircraft.Registration, to.ICAO))
+	}
+
+	mspID, err := requireMSP(stub, from.MSPID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	proposedAt, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read transaction time: %s", err))
+	}
+
+	transfer := AircraftTransfer{
+		Registration: aircraft.Registration,
+		From:         from.ICAO,
+		To:           to.ICAO,
+		ProposedBy:   mspID,
+		ProposedAt:   time.Unix(proposedAt.Seconds, int64(proposedAt.Nanos)).UTC(),
+		TxID:         stub.GetTxID(),
+	}
+	key, err := stub.CreateCompositeKey(transferKeyType, []string{transfer.Registration})
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to create transfer key: %s", err))
+	}
+	transferJSON, err := json.Marshal(transfer)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal transfer: %s", err))
+	}
+	if err := stub.PutState(key, transferJSON); err != nil {
+		return shim.Error(fmt.Sprintf("Failed to store transfer: %s", err))
+	}
+	if err := setTransferEvent(stub, "AircraftTransferProposed", transfer, nil); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(transferJSON)
+}
+
+// AcceptAircraftTransfer completes the pending transfer of an aircraft.
+// Only the organization of the new operator may accept it. The aircraft's
+// reports stay filed under its registration, so they follow it to the new
+// operator.
+func (s *SimpleChaincode) AcceptAircraftTransfer(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	transfer, err := readTransfer(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if transfer == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s has no pending transfer", args[0]))
+	}
+	to, err := readAirline(stub, transfer.To)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if to == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", transfer.To))
+	}
+	if _, err := requireMSP(stub, to.MSPID); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	aircraft, err := readAircraft(stub, transfer.Registration)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if aircraft == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s does not exist", transfer.Registration))
+	}
+	since, err := txDate(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	aircraft.Operator = to.ICAO
+	aircraft.OwnershipChain = append(aircraft.OwnershipChain, OperatorRecord{Operator: to.ICAO, Since: since, TxID: stub.GetTxID()})
+
+	if response := putMasterData(stub, aircraftKeyType, aircraft.Registration, aircraft); response.Status != shim.OK {
+		return response
+	}
+	if err := deleteTransfer(stub, transfer.Registration); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := setTransferEvent(stub, "AircraftTransferred", *transfer, aircraft); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	aircraftJSON, err := json.Marshal(aircraft)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal aircraft: %s", err))
+	}
+	return shim.Success(aircraftJSON)
+}
+
+// CancelAircraftTransfer withdraws or declines a pending transfer. The
+// current operator, the new operator or the regulator may cancel it.
+func (s *SimpleChaincode) CancelAircraftTransfer(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	transfer, err := readTransfer(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if transfer == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s has no pending transfer", args[0]))
+	}
+
+	mspID, err := submitterMSPID(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	allowed := mspID == regulatorMSPID
+	for _, icao := range []string{transfer.From, transfer.To} {
+		airline, err := readAirline(stub, icao)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if airline != nil && airline.MSPID == mspID {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return shim.Error(fmt.Sprintf("Access denied: only %s, %s or the regulator may cancel the transfer, not %s", transfer.From, transfer.To, mspID))
+	}
+
+	if err := deleteTransfer(stub, transfer.Registration); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := setTransferEvent(stub, "AircraftTransferCancelled", *transfer, nil); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(fmt.Sprintf("Transfer of %s cancelled", transfer.Registration)))
+}
+
+// GetPendingTransfers returns the transfers awaiting acceptance
+func (s *SimpleChaincode) GetPendingTransfers(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 0 {
+		return shim.Error("Incorrect number of arguments. Expecting 0")
+	}
+
+	transfers := []AircraftTransfer{}
+	err := readAllMasterData(stub, transferKeyType, func(value []byte) error {
+		var transfer AircraftTransfer
+		if err := json.Unmarshal(value, &transfer); err != nil {
+			return err
+		}
+		transfers = append(transfers, transfer)
+		return nil
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	transfersJSON, err := json.Marshal(transfers)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal transfers: %s", err))
+	}
+
+	return shim.Success(transfersJSON)
+}
+
+// GetAircraftAssets returns every report filed for an aircraft, whichever
+// operator it was filed under. Only the regulator and the organization of
+// the current operator may read them.
+func (s *SimpleChaincode) GetAircraftAssets(stub shim.ChaincodeStubInterface, args []string) peer.Response {
+	if len(args) != 1 {
+		return shim.Error("Incorrect number of arguments. Expecting 1")
+	}
+
+	aircraft, err := readAircraft(stub, args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if aircraft == nil {
+		return shim.Error(fmt.Sprintf("Aircraft %s does not exist", args[0]))
+	}
+	operator, err := readAirline(stub, aircraft.Operator)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if operator == nil {
+		return shim.Error(fmt.Sprintf("Airline %s does not exist", aircraft.Operator))
+	}
+	if err := requireRegulatorOr(stub, operator.MSPID); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	resultsIterator, err := stub.GetStateByRange("", "")
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to read assets: %s", err))
+	}
+	defer resultsIterator.Close()
+
+	assets := []Asset{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return shim.Error(fmt.Sprintf("Error iterating assets: %s", err))
+		}
+
+		var asset Asset
+		if err := json.Unmarshal(result.Value, &asset); err != nil {
+			return shim.Error(fmt.Sprintf("Failed to unmarshal asset: %s", err))
+		}
+		if strings.EqualFold(asset.AircraftID, aircraft.Registration) {
+			assets = append(assets, asset)
+		}
+	}
+
+	assetsJSON, err := json.Marshal(assets)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to marshal assets: %s", err))
+	}
+
+	return shim.Success(assetsJSON)
+}
+
+// requireMSP fails unless the transaction was submitted by the given
+// organization, and returns it
+func requireMSP(stub shim.ChaincodeStubInterface, mspID string) (string, error) {
+	submitter, err := submitterMSPID(stub)
+	if err != nil {
+		return "", err
+	}
+	if submitter != mspID {
+		return "", fmt.Errorf("Access denied: only %s may do this, not %s", mspID, submitter)
+	}
+	return submitter, nil
+}
+
+func readTransfer(stub shim.ChaincodeStubInterface, registration string) (*AircraftTransfer, error) {
+	var transfer AircraftTransfer
+	found, err := readMasterData(stub, transferKeyType, normalizeRegistration(registration), &transfer)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func deleteTransfer(stub shim.ChaincodeStubInterface, registration string) error {
+	key, err := stub.CreateCompositeKey(transferKeyType, []string{registration})
+	if err != nil {
+		return fmt.Errorf("Failed to create transfer key: %s", err)
+	}
+	if err := stub.DelState(key); err != nil {
+		return fmt.Errorf("Failed to delete transfer: %s", err)
+	}
+	return nil
+}
+
+func setTransferEvent(stub shim.ChaincodeStubInterface, name string, transfer AircraftTransfer, aircraft *Aircraft) error {
+	eventJSON, err := json.Marshal(TransferEvent{Type: name, Transfer: transfer, Aircraft: aircraft})
+	if err != nil {
+		return fmt.Errorf("Failed to marshal event: %s", err)
+	}
+	if err := stub.SetEvent(name, eventJSON); err != nil {
+		return fmt.Errorf("Failed to set event: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"aviation-compliance-dapp-oracle/oracle"
+
+	"github.com/joho/godotenv"
+)
+
+// Usage:
+//
+//	go run . [FLIGHT]                                  look up a flight
+//	go run . aircraft TAIL|ICAO24                      look up an aircraft in the registry
+//	go run . refresh-registry [-source S] [-file F]    download the registry dataset
+//	go run . keygen FILE                               create a signing key, print its public key
+func main() {
+	if err := godotenv.Load("../../.env"); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error loading .env file: %v", err)
+	}
+
+	var config oracle.Config
+	if err := config.ApplyEnv(); err != nil {
+		log.Fatalf("Invalid oracle configuration: %v", err)
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "aircraft":
+			lookupAircraft(config, os.Args[2:])
+			return
+		case "refresh-registry":
+			refreshRegistry(config, os.Args[2:])
+			return
+		case "keygen":
+			if len(os.Args) != 3 {
+				log.Fatalf("usage: keygen FILE")
+			}
+			publicKey, err := oracle.GenerateSigningKey(os.Args[2])
+			if err != nil {
+				log.Fatalf("Error generating signing key: %v", err)
+			}
+			fmt.Print(publicKey)
+			return
+		}
+	}
+
+	flightID := "AA100" // Example Flight ID (American Airlines Flight 100)
+	if len(os.Args) > 1 {
+		flightID = os.Args[1]
+	}
+
+	provider, err := oracle.NewProvider(config)
+	if err != nil {
+		log.Fatalf("Error creating oracle provider: %v", err)
+	}
+
+	flightData, err := provider.FetchFlightData(context.Background(), flightID)
+	if err != nil {
+		log.Fatalf("Error fetching flight data: %v", err)
+	}
+
+	fmt.Printf("Provider: %s\n", provider.Name())
+	fmt.Printf("Flight Status: %s\n", flightData.FlightStatus)
+	fmt.Printf("Departure: %s at %s\n", flightData.DepartureCity, flightData.DepartureTime)
+	fmt.Printf("Arrival: %s at %s\n", flightData.ArrivalCity, flightData.ArrivalTime)
+	fmt.Printf("Flight Number: %s\n", flightData.FlightNumber)
+	fmt.Printf("Airline: %s\n", flightData.AirlineName)
+	fmt.Printf("Aircraft: %s\n", flightData.AircraftType)
+}
+
+func lookupAircraft(config oracle.Config, args []string) {
+	if len(args) != 1 {
+		log.Fatalf("usage: aircraft TAIL|ICAO24")
+	}
+	registry, err := oracle.OpenRegistry(config.Registry.File)
+	if err != nil {
+		log.Fatalf("Error opening aircraft registry: %v", err)
+	}
+	record, err := registry.LookupAircraft(context.Background(), args[0])
+	if err != nil {
+		log.Fatalf("Error looking up aircraft: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(record)
+}
+
+func refreshRegistry(config oracle.Config, args []string) {
+	flags := flag.NewFlagSet("refresh-registry", flag.ExitOnError)
+	source := flags.String("source", config.Registry.Source, "URL or file to download the registry dataset from")
+	file := flags.String("file", config.Registry.File, "registry dataset to replace")
+	flags.Parse(args)
+
+	count, err := oracle.RefreshRegistry(context.Background(), *source, *file, nil)
+	if err != nil {
+		log.Fatalf("Error refreshing aircraft registry: %v", err)
+	}
+	fmt.Printf("Loaded %d aircraft into %s\n", count, *file)
+}
@@ -0,0 +1,185 @@
+package oracle
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// attestationVersion prefixes the signed payload. The chaincode builds the
+// same payload to verify attestations, so both must change together.
+const attestationVersion = "aviation-compliance-oracle-attestation/v2"
+
+// Attestation is the oracle's signed statement that provider answered
+// Request with Result at FetchedAt, for the asset AssetID. ResponseHash is
+// the hex SHA-256 of the JSON encoded answer.
+type Attestation struct {
+	AssetID      string `json:"assetId"`
+	OracleID     string `json:"oracleId"`
+	Provider     string `json:"provider"`
+	Request      string `json:"request"`
+	Result       string `json:"result"`
+	ResponseHash string `json:"responseHash"`
+	FetchedAt    string `json:"fetchedAt"`
+	Signature    string `json:"signature"`
+}
+
+// Payload returns the message that is signed: the version and the attested
+// fields, one per line. The asset ID binds the attestation to one report.
+func (a *Attestation) Payload() []byte {
+	return []byte(strings.Join([]string{
+		attestationVersion, a.AssetID, a.OracleID, a.Provider, a.Request, a.Result, a.ResponseHash, a.FetchedAt,
+	}, "\n"))
+}
+
+// SignerConfig names the oracle identity and its ECDSA private key.
+type SignerConfig struct {
+	ID      string `yaml:"id"`
+	KeyFile string `yaml:"keyFile"`
+}
+
+// Signer signs attestations as one oracle identity.
+type Signer struct {
+	id  string
+	key *ecdsa.PrivateKey
+}
+
+// NewSigner loads the private key in config.KeyFile. It returns nil without
+// an error when no key is configured, as attestations are optional.
+func NewSigner(config SignerConfig) (*Signer, error) {
+	if config.KeyFile == "" {
+		return nil, nil
+	}
+	if config.ID == "" {
+		return nil, fmt.Errorf("the oracle signing key needs an oracle ID")
+	}
+
+	data, err := os.ReadFile(config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read oracle signing key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in %s", config.KeyFile)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse oracle signing key: %v", err)
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("the oracle signing key must be an ECDSA key")
+	}
+	return &Signer{id: config.ID, key: ecdsaKey}, nil
+}
+
+// ID returns the oracle identity the signer signs as.
+func (s *Signer) ID() string {
+	return s.id
+}
+
+// PublicKeyPEM returns the public key to register on the ledger.
+func (s *Signer) PublicKeyPEM() (string, error) {
+	return encodePublicKey(&s.key.PublicKey)
+}
+
+// Attest signs the answer response of provider to request, which yielded
+// result, for the report assetID.
+func (s *Signer) Attest(assetID, provider, request, result string, response interface{}, fetchedAt time.Time) (*Attestation, error) {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal oracle response: %v", err)
+	}
+	responseHash := sha256.Sum256(responseJSON)
+
+	attestation := &Attestation{
+		AssetID:      assetID,
+		OracleID:     s.id,
+		Provider:     provider,
+		Request:      request,
+		Result:       result,
+		ResponseHash: hex.EncodeToString(responseHash[:]),
+		FetchedAt:    fetchedAt.UTC().Format(time.RFC3339),
+	}
+	digest := sha256.Sum256(attestation.Payload())
+	signature, err := ecdsa.SignASN1(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign attestation: %v", err)
+	}
+	attestation.Signature = base64.StdEncoding.EncodeToString(signature)
+	return attestation, nil
+}
+
+// Verify checks the attestation's signature against a PEM public key.
+func (a *Attestation) Verify(publicKeyPEM string) error {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return fmt.Errorf("no PEM block found in public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %v", err)
+	}
+	publicKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("the public key must be an ECDSA key")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %v", err)
+	}
+	digest := sha256.Sum256(a.Payload())
+	if !ecdsa.VerifyASN1(publicKey, digest[:], signature) {
+		return fmt.Errorf("attestation signature does not match")
+	}
+	return nil
+}
+
+// GenerateSigningKey writes a new P-256 private key to path and returns the
+// PEM public key to register on the ledger.
+func GenerateSigningKey(path string) (string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal key: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create key file: %v", err)
+	}
+	defer file.Close()
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return "", fmt.Errorf("failed to write key file: %v", err)
+	}
+	return encodePublicKey(&key.PublicKey)
+}
+
+func encodePublicKey(key *ecdsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
@@ -0,0 +1,57 @@
+package oracle
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSignerAttest(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "oracle-key.pem")
+	publicKey, err := GenerateSigningKey(keyFile)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey: %v", err)
+	}
+	if _, err := GenerateSigningKey(keyFile); err == nil {
+		t.Error("expected an existing key file not to be overwritten")
+	}
+
+	signer, err := NewSigner(SignerConfig{ID: "oracle1", KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	if registered, _ := signer.PublicKeyPEM(); registered != publicKey {
+		t.Errorf("expected the signer's public key to match the generated one")
+	}
+
+	record := AircraftRecord{Registration: "PK-GFA", Operator: "Garuda Indonesia"}
+	fetchedAt := time.Date(2024, 12, 1, 15, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+	attestation, err := signer.Attest("asset1", "registry", "PK-GFA", record.Operator, record, fetchedAt)
+	if err != nil {
+		t.Fatalf("Attest: %v", err)
+	}
+	if attestation.OracleID != "oracle1" || attestation.FetchedAt != "2024-12-01T08:00:00Z" || len(attestation.ResponseHash) != 64 {
+		t.Errorf("unexpected attestation %+v", attestation)
+	}
+	if err := attestation.Verify(publicKey); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+
+	for _, tamper := range []func(*Attestation){
+		func(a *Attestation) { a.Result = "Another Airline" },
+		func(a *Attestation) { a.AssetID = "asset2" },
+	} {
+		tampered := *attestation
+		tamper(&tampered)
+		if err := tampered.Verify(publicKey); err == nil {
+			t.Errorf("expected a tampered attestation %+v to fail verification", tampered)
+		}
+	}
+
+	if signer, err := NewSigner(SignerConfig{}); signer != nil || err != nil {
+		t.Errorf("expected no signer without a key, got %v, %v", signer, err)
+	}
+	if _, err := NewSigner(SignerConfig{KeyFile: keyFile}); err == nil {
+		t.Error("expected an error for a key without an oracle ID")
+	}
+}
@@ -0,0 +1,108 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AviationStackConfig configures the aviationstack client. The access key
+// is sent as the access_key parameter, or in the KeyHeader header for
+// gateways that expect it there.
+type AviationStackConfig struct {
+	URL        string       `yaml:"url"`
+	APIKey     string       `yaml:"-"` // from AVIATION_STACK_API_KEY or KeyFile
+	KeyFile    string       `yaml:"keyFile"`
+	KeyHeader  string       `yaml:"keyHeader"`
+	Insecure   bool         `yaml:"insecure"`
+	HTTPClient *http.Client `yaml:"-"`
+}
+
+// AviationStackProvider looks flights up by IATA flight number on
+// aviationstack.com.
+type AviationStackProvider struct {
+	client *AviationStackClient
+}
+
+func NewAviationStackProvider(config AviationStackConfig) (*AviationStackProvider, error) {
+	client, err := NewAviationStackClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &AviationStackProvider{client: client}, nil
+}
+
+func (p *AviationStackProvider) Name() string {
+	return "aviationstack"
+}
+
+func (p *AviationStackProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	body, err := p.fetchRaw(ctx, flightID)
+	if err != nil {
+		return nil, err
+	}
+	return parseAviationStackFlight(body, flightID)
+}
+
+// fetchRaw returns the unparsed /v1/flights response for flightID.
+func (p *AviationStackProvider) fetchRaw(ctx context.Context, flightID string) ([]byte, error) {
+	return p.client.get(ctx, "flights", url.Values{"flight_iata": {flightID}})
+}
+
+// parseAviationStackFlight returns the first flight of a /v1/flights
+// response.
+func parseAviationStackFlight(body []byte, flightID string) (*FlightData, error) {
+	var flights []AviationStackFlight
+	if _, err := decodeAviationStackList(body, &flights); err != nil {
+		return nil, err
+	}
+	if len(flights) == 0 {
+		return nil, fmt.Errorf("no flight data found for flight ID %s: %w", flightID, ErrFlightNotFound)
+	}
+	return flights[0].FlightData(), nil
+}
+
+// FlightData converts the flight to the oracle's FlightData, with Unknown
+// for missing values. Times are the estimated ones, or the scheduled ones
+// without an estimate.
+func (f *AviationStackFlight) FlightData() *FlightData {
+	departure := orUnknown(f.Departure.Estimated, f.Departure.Scheduled)
+	arrival := orUnknown(f.Arrival.Estimated, f.Arrival.Scheduled)
+	aircraftType := unknown
+	if f.Aircraft != nil {
+		aircraftType = orUnknown(f.Aircraft.IATA)
+	}
+
+	return &FlightData{
+		FlightStatus:  orUnknown(f.FlightStatus),
+		Departure:     departure,
+		Arrival:       arrival,
+		FlightNumber:  orUnknown(f.Flight.IATA),
+		AirlineName:   orUnknown(f.Airline.Name),
+		AirlineIATA:   orUnknown(f.Airline.IATA),
+		AirlineICAO:   orUnknown(f.Airline.ICAO),
+		DepartureTime: departure,
+		ArrivalTime:   arrival,
+		DepartureCity: orUnknown(f.Departure.Airport),
+		ArrivalCity:   orUnknown(f.Arrival.Airport),
+		AircraftType:  aircraftType,
+	}
+}
+
+// orUnknown returns the first non-empty value, or Unknown.
+func orUnknown(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return unknown
+}
+
+// redactKey keeps the access key, which is part of the URL, out of errors
+// that end up in logs and responses.
+func redactKey(err error, key string) string {
+	return strings.ReplaceAll(err.Error(), url.QueryEscape(key), "REDACTED")
+}
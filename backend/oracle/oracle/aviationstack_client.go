@@ -0,0 +1,399 @@
+package oracle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const aviationStackBaseURL = "https://api.aviationstack.com/v1"
+
+// maxAviationStackResponse bounds the size of a response body. A page of
+// 100 flights is well below 1 MiB; a body over 4 MiB is refused rather than
+// parsed.
+const maxAviationStackResponse = 4 << 20
+
+// AviationStackClient is a typed client of the aviationstack REST API. The
+// list endpoints return one page of results with its Pagination; use
+// AllPages to follow the pagination.
+type AviationStackClient struct {
+	baseURL   string
+	apiKey    string
+	keyHeader string
+	client    *http.Client
+}
+
+// NewAviationStackClient checks the URL and loads the access key. Keys are
+// only sent over HTTPS unless config.Insecure is set or the server is local,
+// as the free aviationstack plan has no HTTPS.
+func NewAviationStackClient(config AviationStackConfig) (*AviationStackClient, error) {
+	// URL used to be the flights endpoint itself
+	baseURL := strings.TrimSuffix(strings.TrimRight(config.URL, "/"), "/flights")
+	if baseURL == "" {
+		baseURL = aviationStackBaseURL
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aviationstack URL: %v", err)
+	}
+	if parsed.Scheme != "https" && !config.Insecure && !isLoopback(parsed.Hostname()) {
+		return nil, fmt.Errorf("aviationstack URL %s must use https, or set insecure for plans without HTTPS", baseURL)
+	}
+
+	apiKey := config.APIKey
+	if apiKey == "" && config.KeyFile != "" {
+		data, err := os.ReadFile(config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read aviationstack API key: %v", err)
+		}
+		apiKey = strings.TrimSpace(string(data))
+	}
+
+	return &AviationStackClient{
+		baseURL:   baseURL,
+		apiKey:    apiKey,
+		keyHeader: config.KeyHeader,
+		client:    defaultHTTPClient(config.HTTPClient),
+	}, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Pagination describes the page of a list response.
+type Pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Count  int `json:"count"`
+	Total  int `json:"total"`
+}
+
+// More reports whether there are results after this page.
+func (p Pagination) More() bool {
+	return p.Count > 0 && p.Offset+p.Count < p.Total
+}
+
+// AviationStackError is the error object of an aviationstack response, such
+// as invalid_access_key or usage_limit_reached. It wraps the *StatusError
+// of the response when the HTTP status was not 200.
+type AviationStackError struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Context json.RawMessage `json:"context,omitempty"`
+
+	status *StatusError
+}
+
+func (e *AviationStackError) Error() string {
+	if e.status != nil {
+		return fmt.Sprintf("aviationstack error %s (status %d): %s", e.Code, e.status.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("aviationstack error %s: %s", e.Code, e.Message)
+}
+
+func (e *AviationStackError) Unwrap() error {
+	if e.status == nil {
+		return nil
+	}
+	return e.status
+}
+
+// LooseString decodes a JSON string, number or boolean. aviationstack sends
+// some numeric fields as strings and others as numbers.
+type LooseString string
+
+func (s *LooseString) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case nil:
+		*s = ""
+	case string:
+		*s = LooseString(value)
+	case float64:
+		*s = LooseString(strconv.FormatFloat(value, 'f', -1, 64))
+	case bool:
+		*s = LooseString(strconv.FormatBool(value))
+	default:
+		return fmt.Errorf("unexpected JSON %s for a string", data)
+	}
+	return nil
+}
+
+// AviationStackFlight is one result of /flights.
+type AviationStackFlight struct {
+	FlightDate   string                   `json:"flight_date"`
+	FlightStatus string                   `json:"flight_status"`
+	Departure    AviationStackEndpoint    `json:"departure"`
+	Arrival      AviationStackEndpoint    `json:"arrival"`
+	Airline      AviationStackAirlineRef  `json:"airline"`
+	Flight       AviationStackFlightCodes `json:"flight"`
+	Aircraft     *AviationStackAircraft   `json:"aircraft"`
+	Live         *AviationStackLive       `json:"live"`
+}
+
+// AviationStackEndpoint is the departure or arrival of a flight.
+type AviationStackEndpoint struct {
+	Airport         string `json:"airport"`
+	Timezone        string `json:"timezone"`
+	IATA            string `json:"iata"`
+	ICAO            string `json:"icao"`
+	Terminal        string `json:"terminal"`
+	Gate            string `json:"gate"`
+	Baggage         string `json:"baggage,omitempty"`
+	Delay           *int   `json:"delay"`
+	Scheduled       string `json:"scheduled"`
+	Estimated       string `json:"estimated"`
+	Actual          string `json:"actual"`
+	EstimatedRunway string `json:"estimated_runway"`
+	ActualRunway    string `json:"actual_runway"`
+}
+
+// AviationStackAirlineRef is the airline operating a flight.
+type AviationStackAirlineRef struct {
+	Name string `json:"name"`
+	IATA string `json:"iata"`
+	ICAO string `json:"icao"`
+}
+
+// AviationStackFlightCodes are the flight number and the flight it is a
+// codeshare of, if any.
+type AviationStackFlightCodes struct {
+	Number     string                  `json:"number"`
+	IATA       string                  `json:"iata"`
+	ICAO       string                  `json:"icao"`
+	Codeshared *AviationStackCodeshare `json:"codeshared"`
+}
+
+// AviationStackCodeshare is the operating flight of a codeshare.
+type AviationStackCodeshare struct {
+	AirlineName  string `json:"airline_name"`
+	AirlineIATA  string `json:"airline_iata"`
+	AirlineICAO  string `json:"airline_icao"`
+	FlightNumber string `json:"flight_number"`
+	FlightIATA   string `json:"flight_iata"`
+	FlightICAO   string `json:"flight_icao"`
+}
+
+// AviationStackAircraft is the aircraft flying a flight.
+type AviationStackAircraft struct {
+	Registration string `json:"registration"`
+	IATA         string `json:"iata"`
+	ICAO         string `json:"icao"`
+	ICAO24       string `json:"icao24"`
+}
+
+// AviationStackLive is the last position of a flight in the air.
+type AviationStackLive struct {
+	Updated         string  `json:"updated"`
+	Latitude        float64 `json:"latitude"`
+	Longitude       float64 `json:"longitude"`
+	Altitude        float64 `json:"altitude"`
+	Direction       float64 `json:"direction"`
+	SpeedHorizontal float64 `json:"speed_horizontal"`
+	SpeedVertical   float64 `json:"speed_vertical"`
+	IsGround        bool    `json:"is_ground"`
+}
+
+// AviationStackAirline is one result of /airlines.
+type AviationStackAirline struct {
+	AirlineName          string      `json:"airline_name"`
+	IATACode             string      `json:"iata_code"`
+	IATAPrefixAccounting LooseString `json:"iata_prefix_accounting"`
+	ICAOCode             string      `json:"icao_code"`
+	Callsign             string      `json:"callsign"`
+	Type                 string      `json:"type"`
+	Status               string      `json:"status"`
+	FleetSize            LooseString `json:"fleet_size"`
+	FleetAverageAge      LooseString `json:"fleet_average_age"`
+	DateFounded          LooseString `json:"date_founded"`
+	HubCode              string      `json:"hub_code"`
+	CountryName          string      `json:"country_name"`
+	CountryISO2          string      `json:"country_iso2"`
+}
+
+// AviationStackAirplane is one result of /airplanes.
+type AviationStackAirplane struct {
+	RegistrationNumber     string      `json:"registration_number"`
+	ProductionLine         string      `json:"production_line"`
+	IATAType               string      `json:"iata_type"`
+	ModelName              string      `json:"model_name"`
+	ModelCode              string      `json:"model_code"`
+	ICAOCodeHex            string      `json:"icao_code_hex"`
+	IATACodeShort          string      `json:"iata_code_short"`
+	ConstructionNumber     LooseString `json:"construction_number"`
+	TestRegistrationNumber string      `json:"test_registration_number"`
+	RolloutDate            string      `json:"rollout_date"`
+	FirstFlightDate        string      `json:"first_flight_date"`
+	DeliveryDate           string      `json:"delivery_date"`
+	RegistrationDate       string      `json:"registration_date"`
+	LineNumber             LooseString `json:"line_number"`
+	PlaneSeries            LooseString `json:"plane_series"`
+	AirlineIATACode        string      `json:"airline_iata_code"`
+	AirlineICAOCode        string      `json:"airline_icao_code"`
+	PlaneOwner             string      `json:"plane_owner"`
+	EnginesCount           LooseString `json:"engines_count"`
+	EnginesType            string      `json:"engines_type"`
+	PlaneAge               LooseString `json:"plane_age"`
+	PlaneStatus            string      `json:"plane_status"`
+	PlaneClass             string      `json:"plane_class"`
+}
+
+// AviationStackAirport is one result of /airports.
+type AviationStackAirport struct {
+	AirportName  string      `json:"airport_name"`
+	IATACode     string      `json:"iata_code"`
+	ICAOCode     string      `json:"icao_code"`
+	Latitude     LooseString `json:"latitude"`
+	Longitude    LooseString `json:"longitude"`
+	GeonameID    LooseString `json:"geoname_id"`
+	Timezone     string      `json:"timezone"`
+	GMT          LooseString `json:"gmt"`
+	PhoneNumber  string      `json:"phone_number"`
+	CountryName  string      `json:"country_name"`
+	CountryISO2  string      `json:"country_iso2"`
+	CityIATACode string      `json:"city_iata_code"`
+}
+
+// Flights returns a page of /flights, filtered by params such as
+// flight_iata, airline_iata or dep_iata, and limit and offset.
+func (c *AviationStackClient) Flights(ctx context.Context, params url.Values) ([]AviationStackFlight, Pagination, error) {
+	var flights []AviationStackFlight
+	pagination, err := c.list(ctx, "flights", params, &flights)
+	return flights, pagination, err
+}
+
+// Airlines returns a page of /airlines.
+func (c *AviationStackClient) Airlines(ctx context.Context, params url.Values) ([]AviationStackAirline, Pagination, error) {
+	var airlines []AviationStackAirline
+	pagination, err := c.list(ctx, "airlines", params, &airlines)
+	return airlines, pagination, err
+}
+
+// Airplanes returns a page of /airplanes.
+func (c *AviationStackClient) Airplanes(ctx context.Context, params url.Values) ([]AviationStackAirplane, Pagination, error) {
+	var airplanes []AviationStackAirplane
+	pagination, err := c.list(ctx, "airplanes", params, &airplanes)
+	return airplanes, pagination, err
+}
+
+// Airports returns a page of /airports.
+func (c *AviationStackClient) Airports(ctx context.Context, params url.Values) ([]AviationStackAirport, Pagination, error) {
+	var airports []AviationStackAirport
+	pagination, err := c.list(ctx, "airports", params, &airports)
+	return airports, pagination, err
+}
+
+// AllPages calls list with increasing offsets from the one in params until
+// the last page, and returns the results of all pages. limit > 0 stops
+// after limit results. On an error the results so far are returned with it.
+func AllPages[T any](ctx context.Context, list func(context.Context, url.Values) ([]T, Pagination, error), params url.Values, limit int) ([]T, error) {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+
+	var all []T
+	for {
+		page, pagination, err := list(ctx, query)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page...)
+		if limit > 0 && len(all) >= limit {
+			return all[:limit], nil
+		}
+		if len(page) == 0 || !pagination.More() {
+			return all, nil
+		}
+		query.Set("offset", strconv.Itoa(pagination.Offset+len(page)))
+	}
+}
+
+func (c *AviationStackClient) list(ctx context.Context, endpoint string, params url.Values, data interface{}) (Pagination, error) {
+	body, err := c.get(ctx, endpoint, params)
+	if err != nil {
+		return Pagination{}, err
+	}
+	return decodeAviationStackList(body, data)
+}
+
+// decodeAviationStackList decodes the data of a list response into data and
+// returns its pagination.
+func decodeAviationStackList(body []byte, data interface{}) (Pagination, error) {
+	response := struct {
+		Pagination Pagination  `json:"pagination"`
+		Data       interface{} `json:"data"`
+	}{Data: data}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return Pagination{}, fmt.Errorf("failed to parse response JSON: %v", err)
+	}
+	return response.Pagination, nil
+}
+
+// get returns the body of a successful response from endpoint. Error
+// objects are returned as *AviationStackError, other failed responses as
+// *StatusError.
+func (c *AviationStackClient) get(ctx context.Context, endpoint string, params url.Values) ([]byte, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("API key is missing")
+	}
+
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	if c.keyHeader == "" {
+		query.Set("access_key", c.apiKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.keyHeader != "" {
+		req.Header.Set(c.keyHeader, c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", endpoint, redactKey(err, c.apiKey))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAviationStackResponse+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if len(body) > maxAviationStackResponse {
+		return nil, fmt.Errorf("%s response is larger than %d bytes", endpoint, maxAviationStackResponse)
+	}
+
+	var errorResponse struct {
+		Error *AviationStackError `json:"error"`
+	}
+	if json.Unmarshal(body, &errorResponse) == nil && errorResponse.Error != nil {
+		if resp.StatusCode != http.StatusOK {
+			errorResponse.Error.status = newStatusError(resp)
+		}
+		return nil, errorResponse.Error
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+	return body, nil
+}
@@ -0,0 +1,180 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAviationStackClientFlights(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/flights" || r.URL.Query().Get("flight_iata") != "GA404" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"pagination":{"limit":100,"offset":0,"count":1,"total":1},"data":[{
+			"flight_date":"2024-05-02","flight_status":"active",
+			"departure":{"airport":"Soekarno-Hatta International","iata":"CGK","delay":12,"scheduled":"2024-05-02T08:40:00+00:00","estimated":null},
+			"arrival":{"airport":null,"iata":"DPS","baggage":"3"},
+			"airline":{"name":"Garuda Indonesia","iata":"GA","icao":"GIA"},
+			"flight":{"number":"404","iata":"GA404","icao":"GIA404","codeshared":{"airline_name":"klm","flight_iata":"kl4404"}},
+			"aircraft":{"registration":"PK-GFA","iata":"B738","icao":"B738","icao24":"8a01a4"},
+			"live":{"updated":"2024-05-02T09:10:00+00:00","latitude":-7.25,"longitude":112.1,"altitude":10668,"is_ground":false}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewAviationStackClient(AviationStackConfig{URL: server.URL + "/v1", APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewAviationStackClient: %v", err)
+	}
+	flights, pagination, err := client.Flights(context.Background(), url.Values{"flight_iata": {"GA404"}})
+	if err != nil {
+		t.Fatalf("Flights: %v", err)
+	}
+	if len(flights) != 1 || pagination.Total != 1 || pagination.More() {
+		t.Fatalf("unexpected page %+v of %d flights", pagination, len(flights))
+	}
+	flight := flights[0]
+	if *flight.Departure.Delay != 12 || flight.Arrival.Delay != nil || flight.Flight.Codeshared.FlightIATA != "kl4404" || flight.Live.Altitude != 10668 {
+		t.Errorf("unexpected flight %+v", flight)
+	}
+
+	flightData := flight.FlightData()
+	if flightData.DepartureTime != "2024-05-02T08:40:00+00:00" || flightData.ArrivalCity != unknown || flightData.AircraftType != "B738" {
+		t.Errorf("unexpected flight data %+v", flightData)
+	}
+}
+
+func TestAviationStackClientPagination(t *testing.T) {
+	airlines := []string{"Garuda Indonesia", "Citilink", "Lion Air", "Batik Air", "Super Air Jet"}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/airlines" || r.Header.Get("X-Api-Key") != "secret" || r.URL.Query().Has("access_key") {
+			http.Error(w, `{"error":{"code":"missing_access_key","message":"You have not supplied an API Access Key."}}`, http.StatusUnauthorized)
+			return
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		end := offset + 2
+		if end > len(airlines) {
+			end = len(airlines)
+		}
+		data := ""
+		for i, name := range airlines[offset:end] {
+			if i > 0 {
+				data += ","
+			}
+			data += `{"airline_name":"` + name + `","fleet_size":` + strconv.Itoa(i+1) + `,"fleet_average_age":"8.5"}`
+		}
+		w.Write([]byte(`{"pagination":{"limit":2,"offset":` + strconv.Itoa(offset) + `,"count":` + strconv.Itoa(end-offset) +
+			`,"total":` + strconv.Itoa(len(airlines)) + `},"data":[` + data + `]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewAviationStackClient(AviationStackConfig{URL: server.URL, APIKey: "secret", KeyHeader: "X-Api-Key"})
+	if err != nil {
+		t.Fatalf("NewAviationStackClient: %v", err)
+	}
+	all, err := AllPages(context.Background(), client.Airlines, url.Values{"limit": {"2"}}, 0)
+	if err != nil {
+		t.Fatalf("AllPages: %v", err)
+	}
+	if len(all) != 5 || all[4].AirlineName != "Super Air Jet" || all[1].FleetSize != "2" || all[0].FleetAverageAge != "8.5" || requests != 3 {
+		t.Errorf("expected 5 airlines in 3 requests, got %+v in %d", all, requests)
+	}
+
+	requests = 0
+	some, err := AllPages(context.Background(), client.Airlines, nil, 3)
+	if err != nil || len(some) != 3 || requests != 2 {
+		t.Errorf("expected 3 airlines in 2 requests, got %d in %d, %v", len(some), requests, err)
+	}
+}
+
+func TestAviationStackClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("access_key") {
+		case "exhausted":
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"code":"usage_limit_reached","message":"Your monthly usage limit has been reached."}}`))
+		case "secret":
+			w.Write([]byte(`{"error":{"code":"function_access_restricted","message":"Your current subscription plan does not support this API function."}}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":"invalid_access_key","message":"You have not supplied a valid API Access Key."}}`))
+		}
+	}))
+	defer server.Close()
+
+	call := func(key string) error {
+		client, err := NewAviationStackClient(AviationStackConfig{URL: server.URL, APIKey: key})
+		if err != nil {
+			t.Fatalf("NewAviationStackClient: %v", err)
+		}
+		_, _, err = client.Airplanes(context.Background(), nil)
+		return err
+	}
+
+	var apiErr *AviationStackError
+	var statusErr *StatusError
+	if err := call("wrong"); !errors.As(err, &apiErr) || apiErr.Code != "invalid_access_key" || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected invalid_access_key with status 401, got %v", err)
+	}
+	if err := call("exhausted"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests || statusErr.RetryAfter.Seconds() != 60 {
+		t.Errorf("expected a 429 the rate limiter retries, got %v", err)
+	}
+	if err := call("secret"); !errors.As(err, &apiErr) || apiErr.Code != "function_access_restricted" || errors.As(err, &statusErr) {
+		t.Errorf("expected an error object without a status error, got %v", err)
+	}
+
+	if _, err := NewAviationStackClient(AviationStackConfig{URL: "http://api.aviationstack.com/v1"}); err == nil {
+		t.Error("expected a plain HTTP URL to be refused")
+	}
+	if _, err := NewAviationStackClient(AviationStackConfig{URL: "http://api.aviationstack.com/v1", Insecure: true}); err != nil {
+		t.Errorf("expected insecure to allow a plain HTTP URL, got %v", err)
+	}
+}
+
+func TestAviationStackClientRefusesLargeResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":["`))
+		w.Write(make([]byte, maxAviationStackResponse))
+		w.Write([]byte(`"]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewAviationStackClient(AviationStackConfig{URL: server.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatalf("NewAviationStackClient: %v", err)
+	}
+	if _, _, err := client.Airplanes(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("expected an oversized response to be refused, got %v", err)
+	}
+}
+
+func TestAviationStackClientAirports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pagination":{"limit":100,"offset":0,"count":1,"total":1},"data":[{
+			"airport_name":"Soekarno-Hatta International","iata_code":"CGK","icao_code":"WIII",
+			"latitude":"-6.125567","longitude":106.655897,"geoname_id":null,"timezone":"Asia/Jakarta","gmt":"7","country_iso2":"ID"}]}`))
+	}))
+	defer server.Close()
+
+	keyFile := writeFile(t, "aviationstack.key", "secret\n")
+	client, err := NewAviationStackClient(AviationStackConfig{URL: server.URL, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("NewAviationStackClient: %v", err)
+	}
+	airports, _, err := client.Airports(context.Background(), url.Values{"search": {"CGK"}})
+	if err != nil {
+		t.Fatalf("Airports: %v", err)
+	}
+	if len(airports) != 1 || airports[0].ICAOCode != "WIII" || airports[0].Latitude != "-6.125567" || airports[0].Longitude != "106.655897" || airports[0].GeonameID != "" {
+		t.Errorf("unexpected airports %+v", airports)
+	}
+}
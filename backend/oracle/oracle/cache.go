@@ -0,0 +1,173 @@
+package oracle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheConfig keeps flight data for TTL. With a File the cache survives
+// restarts, so a redeploy does not spend the provider quota again.
+type CacheConfig struct {
+	TTL  time.Duration `yaml:"ttl"`
+	File string        `yaml:"file"`
+}
+
+// Stats are the counters of a provider and its wrappers.
+type Stats struct {
+	CacheHits    uint64
+	CacheMisses  uint64
+	CacheEntries int
+	RateLimited  uint64
+	Retries      uint64
+}
+
+// StatsReporter is implemented by providers that count their work.
+type StatsReporter interface {
+	Stats() Stats
+}
+
+func statsOf(provider FlightDataProvider) Stats {
+	if reporter, ok := provider.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{}
+}
+
+type cacheEntry struct {
+	Flight    FlightData `json:"flight"`
+	FetchedAt time.Time  `json:"fetchedAt"`
+}
+
+// CachingProvider answers repeated lookups of a flight from memory until
+// the TTL runs out. Only successful lookups are cached.
+type CachingProvider struct {
+	next FlightDataProvider
+	ttl  time.Duration
+	file string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func NewCachingProvider(next FlightDataProvider, config CacheConfig) (*CachingProvider, error) {
+	provider := &CachingProvider{
+		next:    next,
+		ttl:     config.TTL,
+		file:    config.File,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+	}
+	if provider.file == "" {
+		return provider, nil
+	}
+
+	data, err := os.ReadFile(provider.file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return provider, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read oracle cache: %v", err)
+	}
+	if err := json.Unmarshal(data, &provider.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse oracle cache %s: %v", provider.file, err)
+	}
+	return provider, nil
+}
+
+func (p *CachingProvider) Name() string {
+	return p.next.Name()
+}
+
+// cacheKey keeps entries of different providers apart in a shared file.
+func (p *CachingProvider) cacheKey(flightID string) string {
+	return p.next.Name() + ":" + strings.ToUpper(strings.TrimSpace(flightID))
+}
+
+func (p *CachingProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	key := p.cacheKey(flightID)
+
+	p.mu.Lock()
+	entry, ok := p.entries[key]
+	p.mu.Unlock()
+	if ok && p.now().Sub(entry.FetchedAt) < p.ttl {
+		p.hits.Add(1)
+		flight := entry.Flight
+		flight.FetchedAt = entry.FetchedAt
+		return &flight, nil
+	}
+
+	p.misses.Add(1)
+	flightData, err := p.next.FetchFlightData(ctx, flightID)
+	if err != nil {
+		return nil, err
+	}
+
+	flightData.FetchedAt = p.now()
+	p.mu.Lock()
+	p.entries[key] = cacheEntry{Flight: *flightData, FetchedAt: flightData.FetchedAt}
+	p.expire()
+	err = p.save()
+	p.mu.Unlock()
+	if err != nil {
+		log.Printf("Failed to persist oracle cache: %v", err)
+	}
+	return flightData, nil
+}
+
+// expire drops stale entries. The caller holds p.mu.
+func (p *CachingProvider) expire() {
+	now := p.now()
+	for key, entry := range p.entries {
+		if now.Sub(entry.FetchedAt) >= p.ttl {
+			delete(p.entries, key)
+		}
+	}
+}
+
+// save writes the entries to the cache file, if any. The caller holds p.mu.
+func (p *CachingProvider) save() error {
+	if p.file == "" {
+		return nil
+	}
+	data, err := json.Marshal(p.entries)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p.file), ".oracle-cache-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.file)
+}
+
+// Stats adds the cache counters to those of the wrapped provider.
+func (p *CachingProvider) Stats() Stats {
+	stats := statsOf(p.next)
+	stats.CacheHits += p.hits.Load()
+	stats.CacheMisses += p.misses.Load()
+	p.mu.Lock()
+	stats.CacheEntries += len(p.entries)
+	p.mu.Unlock()
+	return stats
+}
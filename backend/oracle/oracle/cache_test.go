@@ -0,0 +1,145 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProvider knows every flight except XX000 and counts its calls.
+type countingProvider struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	p.calls.Add(1)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if flightID == "XX000" {
+		return nil, ErrFlightNotFound
+	}
+	flight := unknownFlight(flightID)
+	flight.AirlineName = "Airline of " + flightID
+	return flight, nil
+}
+
+func TestCachingProvider(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cache.json")
+	next := &countingProvider{}
+	cache, err := NewCachingProvider(next, CacheConfig{TTL: time.Hour, File: file})
+	if err != nil {
+		t.Fatalf("NewCachingProvider: %v", err)
+	}
+	now := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for _, flightID := range []string{"GA404", "ga404 ", "GA404"} {
+		if _, err := cache.FetchFlightData(context.Background(), flightID); err != nil {
+			t.Fatalf("FetchFlightData(%q): %v", flightID, err)
+		}
+	}
+	if _, err := cache.FetchFlightData(context.Background(), "XX000"); !errors.Is(err, ErrFlightNotFound) {
+		t.Fatalf("expected ErrFlightNotFound, got %v", err)
+	}
+	if _, err := cache.FetchFlightData(context.Background(), "XX000"); !errors.Is(err, ErrFlightNotFound) {
+		t.Fatalf("expected ErrFlightNotFound, got %v", err)
+	}
+	if stats := cache.Stats(); stats.CacheHits != 2 || stats.CacheMisses != 3 || stats.CacheEntries != 1 || next.calls.Load() != 3 {
+		t.Errorf("unexpected stats %+v after %d calls", stats, next.calls.Load())
+	}
+
+	// A new process picks the entry up from disk until it expires
+	restarted, err := NewCachingProvider(next, CacheConfig{TTL: time.Hour, File: file})
+	if err != nil {
+		t.Fatalf("NewCachingProvider: %v", err)
+	}
+	restarted.now = func() time.Time { return now.Add(30 * time.Minute) }
+	flight, err := restarted.FetchFlightData(context.Background(), "GA404")
+	if err != nil || flight.AirlineName != "Airline of GA404" || next.calls.Load() != 3 {
+		t.Errorf("expected a hit from the cache file, got %+v, %v", flight, err)
+	}
+	if err == nil && !flight.FetchedAt.Equal(now) {
+		t.Errorf("expected a cached answer to keep the time it was fetched at, got %v", flight.FetchedAt)
+	}
+
+	restarted.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if _, err := restarted.FetchFlightData(context.Background(), "GA404"); err != nil || next.calls.Load() != 4 {
+		t.Errorf("expected an expired entry to be fetched again, got %v after %d calls", err, next.calls.Load())
+	}
+}
+
+func TestRateLimitedProviderBacksOffOn429(t *testing.T) {
+	next := &countingProvider{err: &StatusError{StatusCode: 429, RetryAfter: 5 * time.Second}}
+	limited := NewRateLimitedProvider(next, RateLimitConfig{MaxRetries: 2, Backoff: time.Second})
+	var slept []time.Duration
+	limited.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	_, err := limited.FetchFlightData(context.Background(), "GA404")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if next.calls.Load() != 3 || len(slept) != 2 || slept[0] != 5*time.Second || slept[1] != 5*time.Second {
+		t.Errorf("expected 3 attempts honouring Retry-After, got %d calls and waits %v", next.calls.Load(), slept)
+	}
+	if stats := limited.Stats(); stats.Retries != 2 || stats.RateLimited != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	next.err = &StatusError{StatusCode: 429}
+	slept = nil
+	limited.FetchFlightData(context.Background(), "GA404")
+	if len(slept) != 2 || slept[0] != time.Second || slept[1] != 2*time.Second {
+		t.Errorf("expected exponential backoff, got %v", slept)
+	}
+
+	next.err = &StatusError{StatusCode: 500}
+	calls := next.calls.Load()
+	if _, err := limited.FetchFlightData(context.Background(), "GA404"); errors.Is(err, ErrRateLimited) || next.calls.Load() != calls+1 {
+		t.Errorf("expected other errors to be returned without retrying, got %v", err)
+	}
+}
+
+func TestRateLimitedProviderTokenBucket(t *testing.T) {
+	next := &countingProvider{}
+	limited := NewRateLimitedProvider(next, RateLimitConfig{Requests: 1, Per: time.Minute, Burst: 2})
+	now := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+	limited.bucket = newTokenBucket(RateLimitConfig{Requests: 1, Per: time.Minute, Burst: 2}, func() time.Time { return now })
+	var slept time.Duration
+	limited.sleep = func(ctx context.Context, d time.Duration) error {
+		slept += d
+		now = now.Add(d)
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := limited.FetchFlightData(context.Background(), "GA404"); err != nil {
+			t.Fatalf("burst request %d: %v", i, err)
+		}
+	}
+	if slept != 0 {
+		t.Errorf("expected the burst to go through at once, waited %s", slept)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := limited.FetchFlightData(ctx, "GA404"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited before the deadline, got %v", err)
+	}
+
+	if _, err := limited.FetchFlightData(context.Background(), "GA404"); err != nil {
+		t.Fatalf("FetchFlightData: %v", err)
+	}
+	if slept != time.Minute || next.calls.Load() != 3 {
+		t.Errorf("expected to wait a minute for the next token, waited %s", slept)
+	}
+}
@@ -0,0 +1,277 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNoQuorum is returned when too few sources agree on the airline.
+var ErrNoQuorum = errors.New("oracle sources did not reach a quorum")
+
+const (
+	// OnConflictRefuse fails a lookup when any two sources disagree.
+	OnConflictRefuse = "refuse"
+	// OnConflictFlag accepts the quorum answer and reports the conflicts,
+	// so the report can be flagged for review.
+	OnConflictFlag = "flag"
+)
+
+// ConsensusConfig configures the consensus provider. Sources are provider
+// names, each configured by its own section of Config. Quorum is the number
+// of sources that must agree on the airline, a majority by default.
+type ConsensusConfig struct {
+	Sources    []string `yaml:"sources"`
+	Quorum     int      `yaml:"quorum"`
+	OnConflict string   `yaml:"onConflict"`
+}
+
+// SourceAnswer is what one source answered.
+type SourceAnswer struct {
+	Provider string      `json:"provider"`
+	Flight   *FlightData `json:"flight,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// Conflict lists the differing values of one field by provider.
+type Conflict struct {
+	Field  string            `json:"field"`
+	Values map[string]string `json:"values"`
+}
+
+// ConsensusResult is the agreed flight data with the answer of every source
+// and the fields they disagreed on.
+type ConsensusResult struct {
+	Flight    *FlightData    `json:"flight,omitempty"`
+	Agreeing  int            `json:"agreeing"`
+	Quorum    int            `json:"quorum"`
+	Sources   []SourceAnswer `json:"sources"`
+	Conflicts []Conflict     `json:"conflicts,omitempty"`
+}
+
+// ConflictError is returned when the sources disagree beyond what the
+// configured rule accepts. It matches ErrNoQuorum with errors.Is.
+type ConflictError struct {
+	Result *ConsensusResult
+}
+
+func (e *ConflictError) Error() string {
+	fields := make([]string, 0, len(e.Result.Conflicts))
+	for _, conflict := range e.Result.Conflicts {
+		fields = append(fields, conflict.Field)
+	}
+	if len(fields) == 0 {
+		return fmt.Sprintf("%d oracle sources named the airline, %d required", e.Result.Agreeing, e.Result.Quorum)
+	}
+	return fmt.Sprintf("oracle sources disagree on %s (%d agreeing, quorum %d)",
+		strings.Join(fields, ", "), e.Result.Agreeing, e.Result.Quorum)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrNoQuorum
+}
+
+// ConsensusProvider asks several providers at once and answers with the
+// airline enough of them agree on.
+type ConsensusProvider struct {
+	sources    []FlightDataProvider
+	quorum     int
+	onConflict string
+}
+
+func NewConsensusProvider(sources []FlightDataProvider, config ConsensusConfig) (*ConsensusProvider, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("the consensus oracle provider needs sources")
+	}
+	quorum := config.Quorum
+	if quorum <= 0 {
+		quorum = len(sources)/2 + 1
+	}
+	if quorum > len(sources) {
+		return nil, fmt.Errorf("quorum %d is larger than the %d oracle sources", quorum, len(sources))
+	}
+
+	switch config.OnConflict {
+	case "":
+		config.OnConflict = OnConflictRefuse
+	case OnConflictRefuse, OnConflictFlag:
+	default:
+		return nil, fmt.Errorf("unknown onConflict rule %q", config.OnConflict)
+	}
+	return &ConsensusProvider{sources: sources, quorum: quorum, onConflict: config.OnConflict}, nil
+}
+
+func (p *ConsensusProvider) Name() string {
+	return "consensus"
+}
+
+func (p *ConsensusProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	result, err := p.Resolve(ctx, flightID)
+	if err != nil {
+		return nil, err
+	}
+	return result.Flight, nil
+}
+
+// Resolve queries every source in parallel and applies the quorum rule. The
+// result lists the conflicts even when the rule accepts them.
+func (p *ConsensusProvider) Resolve(ctx context.Context, flightID string) (*ConsensusResult, error) {
+	answers := make([]SourceAnswer, len(p.sources))
+	var wg sync.WaitGroup
+	for i, source := range p.sources {
+		wg.Add(1)
+		go func(i int, source FlightDataProvider) {
+			defer wg.Done()
+			answers[i].Provider = source.Name()
+			flight, err := source.FetchFlightData(ctx, flightID)
+			if err != nil {
+				answers[i].Error = err.Error()
+				return
+			}
+			answers[i].Flight = flight
+		}(i, source)
+	}
+	wg.Wait()
+
+	result := &ConsensusResult{Quorum: p.quorum, Sources: answers}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var agreed FlightData
+	for _, field := range consensusFields {
+		value, count, conflict := vote(answers, field)
+		field.set(&agreed, value)
+		if conflict != nil {
+			result.Conflicts = append(result.Conflicts, *conflict)
+		}
+		if field.name == "airline_name" {
+			result.Agreeing = count
+		}
+	}
+
+	if result.Agreeing < p.quorum {
+		return result, &ConflictError{Result: result}
+	}
+	if len(result.Conflicts) > 0 && p.onConflict == OnConflictRefuse {
+		return result, &ConflictError{Result: result}
+	}
+
+	// Fields the vote does not cover come from the first agreeing source
+	for _, answer := range answers {
+		if answer.Flight != nil && normalizeAirline(answer.Flight.AirlineName) == normalizeAirline(agreed.AirlineName) {
+			flight := *answer.Flight
+			for _, field := range consensusFields {
+				field.set(&flight, field.get(&agreed))
+			}
+			result.Flight = &flight
+			break
+		}
+	}
+	return result, nil
+}
+
+// Stats adds up the counters of all sources.
+func (p *ConsensusProvider) Stats() Stats {
+	var stats Stats
+	for _, source := range p.sources {
+		sourceStats := statsOf(source)
+		stats.CacheHits += sourceStats.CacheHits
+		stats.CacheMisses += sourceStats.CacheMisses
+		stats.CacheEntries += sourceStats.CacheEntries
+		stats.RateLimited += sourceStats.RateLimited
+		stats.Retries += sourceStats.Retries
+	}
+	return stats
+}
+
+// consensusField is a FlightData field the sources vote on.
+type consensusField struct {
+	name      string
+	get       func(*FlightData) string
+	set       func(*FlightData, string)
+	normalize func(string) string
+}
+
+var consensusFields = []consensusField{
+	{"airline_name", func(f *FlightData) string { return f.AirlineName }, func(f *FlightData, v string) { f.AirlineName = v }, normalizeAirline},
+	{"airline_iata", func(f *FlightData) string { return f.AirlineIATA }, func(f *FlightData, v string) { f.AirlineIATA = v }, normalizeCode},
+	{"airline_icao", func(f *FlightData) string { return f.AirlineICAO }, func(f *FlightData, v string) { f.AirlineICAO = v }, normalizeCode},
+	{"aircraft_type", func(f *FlightData) string { return f.AircraftType }, func(f *FlightData, v string) { f.AircraftType = v }, normalizeCode},
+}
+
+// vote returns the most common known value of field among the answers, the
+// number of sources giving it and, if sources gave different values, the
+// conflict. Ties go to the source listed first.
+func vote(answers []SourceAnswer, field consensusField) (string, int, *Conflict) {
+	counts := make(map[string]int)
+	first := make(map[string]string)
+	values := make(map[string]string)
+	var order []string
+	for _, answer := range answers {
+		if answer.Flight == nil {
+			continue
+		}
+		value := field.get(answer.Flight)
+		key := field.normalize(value)
+		if key == "" {
+			continue
+		}
+		values[answer.Provider] = value
+		if _, ok := counts[key]; !ok {
+			order = append(order, key)
+			first[key] = value
+		}
+		counts[key]++
+	}
+	if len(order) == 0 {
+		return unknown, 0, nil
+	}
+
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	best := order[0]
+	var conflict *Conflict
+	if len(order) > 1 {
+		conflict = &Conflict{Field: field.name, Values: values}
+	}
+	return first[best], counts[best], conflict
+}
+
+var (
+	nonAlphanumeric   = regexp.MustCompile(`[^A-Z0-9]+`)
+	corporateSuffixes = map[string]bool{
+		"INC": true, "LTD": true, "LLC": true, "PLC": true, "CO": true, "CORP": true,
+		"CORPORATION": true, "COMPANY": true, "PT": true, "TBK": true, "PERSERO": true,
+	}
+)
+
+// normalizeAirline makes "PT Garuda Indonesia (Persero) Tbk" and "Garuda
+// Indonesia" compare equal. Unknown values normalize to "".
+func normalizeAirline(name string) string {
+	words := strings.Fields(nonAlphanumeric.ReplaceAllString(strings.ToUpper(name), " "))
+	kept := words[:0]
+	for _, word := range words {
+		if !corporateSuffixes[word] {
+			kept = append(kept, word)
+		}
+	}
+	normalized := strings.Join(kept, " ")
+	if normalized == strings.ToUpper(unknown) {
+		return ""
+	}
+	return normalized
+}
+
+// normalizeCode makes codes such as "b738", "B-738" and "B738" compare
+// equal. Unknown values normalize to "".
+func normalizeCode(code string) string {
+	normalized := nonAlphanumeric.ReplaceAllString(strings.ToUpper(code), "")
+	if normalized == strings.ToUpper(unknown) {
+		return ""
+	}
+	return normalized
+}
@@ -0,0 +1,97 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fixedProvider answers every flight with the same airline and aircraft.
+type fixedProvider struct {
+	name    string
+	airline string
+	iata    string
+	icao    string
+	err     error
+}
+
+func (p *fixedProvider) Name() string { return p.name }
+
+func (p *fixedProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	flight := unknownFlight(flightID)
+	flight.AirlineName = p.airline
+	flight.AirlineIATA = p.iata
+	flight.AirlineICAO = p.icao
+	flight.AircraftType = "B738"
+	return flight, nil
+}
+
+func TestConsensusProviderAgrees(t *testing.T) {
+	consensus, err := NewConsensusProvider([]FlightDataProvider{
+		&fixedProvider{name: "aviationstack", airline: "Garuda Indonesia", iata: "GA", icao: "GIA"},
+		&fixedProvider{name: "opensky", airline: "PT Garuda Indonesia (Persero) Tbk", iata: "ga", icao: "GIA"},
+		&fixedProvider{name: "registry", err: ErrFlightNotFound},
+	}, ConsensusConfig{})
+	if err != nil {
+		t.Fatalf("NewConsensusProvider: %v", err)
+	}
+
+	result, err := consensus.Resolve(context.Background(), "GA404")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if result.Flight.AirlineName != "Garuda Indonesia" || result.Agreeing != 2 || result.Quorum != 2 || len(result.Conflicts) != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if result.Sources[2].Provider != "registry" || result.Sources[2].Error == "" {
+		t.Errorf("expected the failing source to be reported, got %+v", result.Sources[2])
+	}
+}
+
+func TestConsensusProviderConflicts(t *testing.T) {
+	sources := []FlightDataProvider{
+		&fixedProvider{name: "aviationstack", airline: "Garuda Indonesia", iata: "GA"},
+		&fixedProvider{name: "opensky", airline: "Garuda Indonesia", iata: "GA"},
+		&fixedProvider{name: "static", airline: "Citilink", iata: "QG"},
+	}
+
+	refuse, err := NewConsensusProvider(sources, ConsensusConfig{})
+	if err != nil {
+		t.Fatalf("NewConsensusProvider: %v", err)
+	}
+	result, err := refuse.Resolve(context.Background(), "GA404")
+	var conflictErr *ConflictError
+	if !errors.As(err, &conflictErr) || !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	if len(result.Conflicts) != 2 || result.Conflicts[0].Field != "airline_name" || result.Conflicts[0].Values["static"] != "Citilink" {
+		t.Errorf("unexpected conflicts %+v", result.Conflicts)
+	}
+
+	flag, err := NewConsensusProvider(sources, ConsensusConfig{OnConflict: OnConflictFlag})
+	if err != nil {
+		t.Fatalf("NewConsensusProvider: %v", err)
+	}
+	result, err = flag.Resolve(context.Background(), "GA404")
+	if err != nil {
+		t.Fatalf("expected the majority to be accepted, got %v", err)
+	}
+	if result.Flight.AirlineName != "Garuda Indonesia" || result.Flight.AirlineIATA != "GA" || len(result.Conflicts) != 2 {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	unanimous, err := NewConsensusProvider(sources, ConsensusConfig{Quorum: 3, OnConflict: OnConflictFlag})
+	if err != nil {
+		t.Fatalf("NewConsensusProvider: %v", err)
+	}
+	if _, err := unanimous.Resolve(context.Background(), "GA404"); !errors.Is(err, ErrNoQuorum) {
+		t.Errorf("expected ErrNoQuorum without a unanimous answer, got %v", err)
+	}
+
+	if _, err := NewConsensusProvider(sources, ConsensusConfig{Quorum: 4}); err == nil {
+		t.Errorf("expected a quorum above the number of sources to be rejected")
+	}
+}
@@ -0,0 +1,117 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FixturesConfig points the replay and record providers at a directory of
+// aviationstack responses, one <FLIGHT>.json file per flight.
+type FixturesConfig struct {
+	Dir string `yaml:"dir"`
+}
+
+var unsafeFixtureChars = regexp.MustCompile(`[^A-Z0-9_-]`)
+
+// fixturePath maps a flight identifier to its file, keeping it inside dir.
+func fixturePath(dir, flightID string) string {
+	name := unsafeFixtureChars.ReplaceAllString(strings.ToUpper(strings.TrimSpace(flightID)), "_")
+	return filepath.Join(dir, name+".json")
+}
+
+// ReplayProvider answers from recorded aviationstack responses and never
+// goes to the network, so the API and its tests run offline with the same
+// answers every time.
+type ReplayProvider struct {
+	dir string
+}
+
+func NewReplayProvider(config FixturesConfig) (*ReplayProvider, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("the replay oracle provider needs a fixtures directory")
+	}
+	info, err := os.Stat(config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fixtures directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("fixtures path %s is not a directory", config.Dir)
+	}
+	return &ReplayProvider{dir: config.Dir}, nil
+}
+
+func (p *ReplayProvider) Name() string {
+	return "replay"
+}
+
+func (p *ReplayProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	body, err := os.ReadFile(fixturePath(p.dir, flightID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no recorded response for flight ID %s: %w", flightID, ErrFlightNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded response: %v", err)
+	}
+	return parseAviationStackFlight(body, flightID)
+}
+
+// RecordProvider queries aviationstack and saves every successful response
+// to the fixtures directory for ReplayProvider.
+type RecordProvider struct {
+	live *AviationStackProvider
+	dir  string
+}
+
+func NewRecordProvider(config FixturesConfig, live AviationStackConfig) (*RecordProvider, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("the record oracle provider needs a fixtures directory")
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create fixtures directory: %v", err)
+	}
+	liveProvider, err := NewAviationStackProvider(live)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordProvider{live: liveProvider, dir: config.Dir}, nil
+}
+
+func (p *RecordProvider) Name() string {
+	return "record"
+}
+
+func (p *RecordProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	body, err := p.live.fetchRaw(ctx, flightID)
+	if err != nil {
+		return nil, err
+	}
+	flightData, err := parseAviationStackFlight(body, flightID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write through a temporary file so a replaying process never sees a
+	// half-written fixture
+	path := fixturePath(p.dir, flightID)
+	tmp, err := os.CreateTemp(p.dir, ".record-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to record response: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to record response: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to record response: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to record response: %v", err)
+	}
+	return flightData, nil
+}
@@ -0,0 +1,86 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestReplayProvider(t *testing.T) {
+	provider, err := NewProvider(Config{Provider: "replay", Fixtures: FixturesConfig{Dir: "../fixtures"}})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+
+	flight, err := provider.FetchFlightData(context.Background(), "ga404")
+	if err != nil {
+		t.Fatalf("FetchFlightData: %v", err)
+	}
+	if flight.AirlineName != "Garuda Indonesia" || flight.FlightNumber != "GA404" || flight.AircraftType != "B738" {
+		t.Errorf("unexpected flight data: %+v", flight)
+	}
+
+	if _, err := provider.FetchFlightData(context.Background(), "XX000"); !errors.Is(err, ErrFlightNotFound) {
+		t.Errorf("expected ErrFlightNotFound, got %v", err)
+	}
+	if _, err := provider.FetchFlightData(context.Background(), "../go"); !errors.Is(err, ErrFlightNotFound) {
+		t.Errorf("expected a path outside the fixtures to be not found, got %v", err)
+	}
+	if _, err := NewReplayProvider(FixturesConfig{Dir: t.TempDir() + "/missing"}); err == nil {
+		t.Error("expected an error for a missing fixtures directory")
+	}
+}
+
+func TestRecordProviderReplays(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("flight_iata") != "GA404" {
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		w.Write([]byte(aviationStackFixture))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir() + "/recorded"
+	fixtures := FixturesConfig{Dir: dir}
+	recorder, err := NewRecordProvider(fixtures, AviationStackConfig{URL: server.URL, APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewRecordProvider: %v", err)
+	}
+	recorded, err := recorder.FetchFlightData(context.Background(), "GA404")
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if _, err := recorder.FetchFlightData(context.Background(), "XX000"); !errors.Is(err, ErrFlightNotFound) {
+		t.Errorf("expected ErrFlightNotFound, got %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "GA404.json" {
+		t.Fatalf("expected only GA404.json to be recorded, got %v", entries)
+	}
+
+	server.Close()
+	replay, err := NewReplayProvider(fixtures)
+	if err != nil {
+		t.Fatalf("NewReplayProvider: %v", err)
+	}
+	replayed, err := replay.FetchFlightData(context.Background(), "GA404")
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if *replayed != *recorded {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 live requests, got %d", requests)
+	}
+}
@@ -0,0 +1,133 @@
+package oracle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const openSkyAPIURL = "https://opensky-network.org/api"
+
+// OpenSkyConfig configures the OpenSky-style provider. State vectors carry
+// ICAO callsigns only, so IATA flight numbers are translated with
+// IATAToICAO and airline names come from Airlines.
+type OpenSkyConfig struct {
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"-"` // from OPENSKY_PASSWORD only
+	// IATAToICAO maps IATA airline codes to ICAO designators, e.g. GA: GIA
+	IATAToICAO map[string]string `yaml:"iataToIcao"`
+	// Airlines maps ICAO designators to airline names, e.g. GIA: Garuda Indonesia
+	Airlines   map[string]string `yaml:"airlines"`
+	HTTPClient *http.Client      `yaml:"-"`
+}
+
+// OpenSkyProvider finds a flight among the live state vectors of an
+// OpenSky-style /states/all endpoint by its callsign.
+type OpenSkyProvider struct {
+	config OpenSkyConfig
+	client *http.Client
+}
+
+func NewOpenSkyProvider(config OpenSkyConfig) *OpenSkyProvider {
+	if config.URL == "" {
+		config.URL = openSkyAPIURL
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &OpenSkyProvider{config: config, client: defaultHTTPClient(config.HTTPClient)}
+}
+
+func (p *OpenSkyProvider) Name() string {
+	return "opensky"
+}
+
+// callsign turns an IATA flight number such as GA123 into the ICAO callsign
+// GIA123 when the airline is known; other identifiers are used as given.
+func (p *OpenSkyProvider) callsign(flightID string) string {
+	flightID = strings.ToUpper(strings.TrimSpace(flightID))
+	if len(flightID) > 2 {
+		if icao, ok := p.config.IATAToICAO[flightID[:2]]; ok {
+			return icao + flightID[2:]
+		}
+	}
+	return flightID
+}
+
+// airlineICAO returns the ICAO designator a callsign starts with, if it is
+// a known airline.
+func (p *OpenSkyProvider) airlineICAO(callsign string) (string, bool) {
+	if len(callsign) < 3 {
+		return "", false
+	}
+	if _, ok := p.config.Airlines[callsign[:3]]; ok {
+		return callsign[:3], true
+	}
+	for _, icao := range p.config.IATAToICAO {
+		if icao == callsign[:3] {
+			return icao, true
+		}
+	}
+	return "", false
+}
+
+func (p *OpenSkyProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.URL+"/states/all", nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.config.Username != "" {
+		req.SetBasicAuth(p.config.Username, p.config.Password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch flight data: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	// Each state is an array: icao24, callsign, origin_country,
+	// time_position, last_contact, longitude, latitude, baro_altitude,
+	// on_ground, ...
+	var body struct {
+		States [][]interface{} `json:"states"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse response JSON: %v", err)
+	}
+
+	callsign := p.callsign(flightID)
+	for _, state := range body.States {
+		if len(state) < 9 {
+			continue
+		}
+		stateCallsign, _ := state[1].(string)
+		if strings.ToUpper(strings.TrimSpace(stateCallsign)) != callsign {
+			continue
+		}
+
+		flightData := unknownFlight(flightID)
+		flightData.FlightStatus = "active"
+		if onGround, _ := state[8].(bool); onGround {
+			flightData.FlightStatus = "on-ground"
+		}
+		if icao, ok := p.airlineICAO(callsign); ok {
+			flightData.AirlineICAO = icao
+			if airline, ok := p.config.Airlines[icao]; ok {
+				flightData.AirlineName = airline
+			}
+			for iata, code := range p.config.IATAToICAO {
+				if code == icao {
+					flightData.AirlineIATA = iata
+				}
+			}
+		}
+		return flightData, nil
+	}
+	return nil, fmt.Errorf("no state vector found for callsign %s: %w", callsign, ErrFlightNotFound)
+}
@@ -0,0 +1,246 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlightData is the flight information used to fill in compliance reports.
+// Fields a provider does not know are set to "Unknown".
+type FlightData struct {
+	FlightStatus  string `json:"flight_status"`
+	Departure     string `json:"departure"`
+	Arrival       string `json:"arrival"`
+	FlightNumber  string `json:"flight_number"`
+	AirlineName   string `json:"airline_name"`
+	AirlineIATA   string `json:"airline_iata"`
+	AirlineICAO   string `json:"airline_icao"`
+	AircraftType  string `json:"aircraft_type"`
+	DepartureTime string `json:"departure_time"`
+	ArrivalTime   string `json:"arrival_time"`
+	DepartureCity string `json:"departure_city"`
+	ArrivalCity   string `json:"arrival_city"`
+	// FetchedAt is when the source answered, if a cache kept the answer
+	FetchedAt time.Time `json:"-"`
+}
+
+const unknown = "Unknown"
+
+// unknownFlight returns FlightData for flightID with every other field
+// unknown.
+func unknownFlight(flightID string) *FlightData {
+	return &FlightData{
+		FlightStatus:  unknown,
+		Departure:     unknown,
+		Arrival:       unknown,
+		FlightNumber:  flightID,
+		AirlineName:   unknown,
+		AirlineIATA:   unknown,
+		AirlineICAO:   unknown,
+		AircraftType:  unknown,
+		DepartureTime: unknown,
+		ArrivalTime:   unknown,
+		DepartureCity: unknown,
+		ArrivalCity:   unknown,
+	}
+}
+
+// ErrFlightNotFound is returned when a provider has no data for a flight.
+var ErrFlightNotFound = errors.New("flight not found")
+
+// FlightDataProvider looks up the flight data for the flight or aircraft
+// identifier given in a compliance report.
+type FlightDataProvider interface {
+	Name() string
+	FetchFlightData(ctx context.Context, flightID string) (*FlightData, error)
+}
+
+// Config selects and configures the provider. It can be embedded in a YAML
+// file and is overridden from the environment by ApplyEnv.
+type Config struct {
+	Provider      string              `yaml:"provider"`
+	AviationStack AviationStackConfig `yaml:"aviationstack"`
+	OpenSky       OpenSkyConfig       `yaml:"opensky"`
+	Static        StaticConfig        `yaml:"static"`
+	Registry      RegistryConfig      `yaml:"registry"`
+	Fixtures      FixturesConfig      `yaml:"fixtures"`
+	Cache         CacheConfig         `yaml:"cache"`
+	RateLimit     RateLimitConfig     `yaml:"rateLimit"`
+	Signer        SignerConfig        `yaml:"signer"`
+	Consensus     ConsensusConfig     `yaml:"consensus"`
+}
+
+// ApplyEnv overrides the config with ORACLE_PROVIDER, AVIATION_STACK_URL,
+// AVIATION_STACK_API_KEY, AVIATION_STACK_API_KEY_FILE, OPENSKY_URL,
+// OPENSKY_USERNAME, OPENSKY_PASSWORD,
+// ORACLE_STATIC_FILE, ORACLE_REGISTRY_FILE, ORACLE_REGISTRY_SOURCE,
+// ORACLE_FIXTURES_DIR, ORACLE_CACHE_TTL, ORACLE_CACHE_FILE,
+// ORACLE_RATE_LIMIT_REQUESTS, ORACLE_RATE_LIMIT_PER, ORACLE_ID,
+// ORACLE_SIGNING_KEY and ORACLE_SOURCES (comma separated).
+func (c *Config) ApplyEnv() error {
+	overrideString(&c.Provider, "ORACLE_PROVIDER")
+	overrideString(&c.AviationStack.URL, "AVIATION_STACK_URL")
+	overrideString(&c.AviationStack.APIKey, "AVIATION_STACK_API_KEY")
+	overrideString(&c.AviationStack.KeyFile, "AVIATION_STACK_API_KEY_FILE")
+	overrideString(&c.OpenSky.URL, "OPENSKY_URL")
+	overrideString(&c.OpenSky.Username, "OPENSKY_USERNAME")
+	overrideString(&c.OpenSky.Password, "OPENSKY_PASSWORD")
+	overrideString(&c.Static.File, "ORACLE_STATIC_FILE")
+	overrideString(&c.Registry.File, "ORACLE_REGISTRY_FILE")
+	overrideString(&c.Registry.Source, "ORACLE_REGISTRY_SOURCE")
+	overrideString(&c.Fixtures.Dir, "ORACLE_FIXTURES_DIR")
+	overrideString(&c.Cache.File, "ORACLE_CACHE_FILE")
+	overrideString(&c.Signer.ID, "ORACLE_ID")
+	overrideString(&c.Signer.KeyFile, "ORACLE_SIGNING_KEY")
+	if sources := os.Getenv("ORACLE_SOURCES"); sources != "" {
+		c.Consensus.Sources = strings.Split(sources, ",")
+		for i := range c.Consensus.Sources {
+			c.Consensus.Sources[i] = strings.TrimSpace(c.Consensus.Sources[i])
+		}
+	}
+	if err := overrideDuration(&c.Cache.TTL, "ORACLE_CACHE_TTL"); err != nil {
+		return err
+	}
+	if err := overrideDuration(&c.RateLimit.Per, "ORACLE_RATE_LIMIT_PER"); err != nil {
+		return err
+	}
+	if value := os.Getenv("ORACLE_RATE_LIMIT_REQUESTS"); value != "" {
+		requests, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid ORACLE_RATE_LIMIT_REQUESTS: %w", err)
+		}
+		c.RateLimit.Requests = requests
+	}
+	return nil
+}
+
+// NewProvider creates the provider named by config.Provider:
+//
+//	aviationstack (default)  live flights from aviationstack.com
+//	opensky                  live state vectors from an OpenSky-style API
+//	static                   flights from a JSON file
+//	registry                 operator and type from an aircraft registry file
+//	replay                   recorded aviationstack responses, offline
+//	record                   aviationstack, saving responses for replay
+//	consensus                all of config.Consensus.Sources, with a quorum
+//
+// Requests to a provider that calls a remote API are limited to
+// config.RateLimit, and its answers are cached for config.Cache.TTL when it
+// is set. For consensus this applies to each such source; local files are
+// read as they are.
+func NewProvider(config Config) (FlightDataProvider, error) {
+	if config.Provider == "consensus" {
+		return newConsensusProvider(config)
+	}
+
+	provider, err := newBaseProvider(config)
+	if err != nil || !remoteProvider(config.Provider) {
+		return provider, err
+	}
+	if config.RateLimit.enabled() || config.RateLimit.MaxRetries > 0 {
+		provider = NewRateLimitedProvider(provider, config.RateLimit)
+	}
+	if config.Cache.TTL > 0 {
+		return NewCachingProvider(provider, config.Cache)
+	}
+	return provider, nil
+}
+
+func newConsensusProvider(config Config) (*ConsensusProvider, error) {
+	sources := make([]FlightDataProvider, 0, len(config.Consensus.Sources))
+	for _, name := range config.Consensus.Sources {
+		if name == "consensus" {
+			return nil, fmt.Errorf("the consensus oracle provider cannot be its own source")
+		}
+		sourceConfig := config
+		sourceConfig.Provider = name
+		source, err := NewProvider(sourceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("oracle source %s: %v", name, err)
+		}
+		sources = append(sources, source)
+	}
+	return NewConsensusProvider(sources, config.Consensus)
+}
+
+// remoteProvider reports whether the named provider calls a remote API, as
+// opposed to reading local files.
+func remoteProvider(name string) bool {
+	switch name {
+	case "", "aviationstack", "opensky", "record":
+		return true
+	}
+	return false
+}
+
+// AircraftProvider reports whether the named provider can look up an
+// aircraft by tail number or ICAO address, as opposed to a flight by flight
+// number. A static file is keyed by whatever identifiers it lists.
+func AircraftProvider(name string) bool {
+	switch name {
+	case "registry", "static":
+		return true
+	}
+	return false
+}
+
+func newBaseProvider(config Config) (FlightDataProvider, error) {
+	switch config.Provider {
+	case "", "aviationstack":
+		return NewAviationStackProvider(config.AviationStack)
+	case "opensky":
+		return NewOpenSkyProvider(config.OpenSky), nil
+	case "static":
+		return NewStaticProvider(config.Static)
+	case "registry":
+		return NewRegistryProvider(config.Registry)
+	case "replay":
+		return NewReplayProvider(config.Fixtures)
+	case "record":
+		return NewRecordProvider(config.Fixtures, config.AviationStack)
+	default:
+		return nil, fmt.Errorf("unknown oracle provider %q", config.Provider)
+	}
+}
+
+// NewProviderFromEnv creates the provider configured by the environment
+// alone, see Config.ApplyEnv.
+func NewProviderFromEnv() (FlightDataProvider, error) {
+	var config Config
+	if err := config.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	return NewProvider(config)
+}
+
+func overrideString(target *string, name string) {
+	if value := os.Getenv(name); value != "" {
+		*target = value
+	}
+}
+
+func overrideDuration(target *time.Duration, name string) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = duration
+	return nil
+}
+
+func defaultHTTPClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
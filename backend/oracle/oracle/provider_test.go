@@ -0,0 +1,169 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const aviationStackFixture = `{"data":[{"flight_status":"scheduled",
+	"departure":{"airport":"Soekarno-Hatta","estimated":"2024-01-01T08:00:00+00:00"},
+	"arrival":{"airport":"Ngurah Rai","estimated":"2024-01-01T10:00:00+00:00"},
+	"airline":{"name":"Garuda Indonesia"},
+	"flight":{"iata":"GA404"},
+	"aircraft":null}]}`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAviationStackProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_key") != "secret" || r.URL.Query().Get("flight_iata") != "GA404" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(aviationStackFixture))
+	}))
+	defer server.Close()
+
+	provider, err := NewAviationStackProvider(AviationStackConfig{URL: server.URL, APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewAviationStackProvider: %v", err)
+	}
+	flight, err := provider.FetchFlightData(context.Background(), "GA404")
+	if err != nil {
+		t.Fatalf("FetchFlightData: %v", err)
+	}
+	if flight.AirlineName != "Garuda Indonesia" || flight.DepartureCity != "Soekarno-Hatta" || flight.AircraftType != unknown {
+		t.Errorf("unexpected flight data: %+v", flight)
+	}
+
+	withoutKey, err := NewAviationStackProvider(AviationStackConfig{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewAviationStackProvider: %v", err)
+	}
+	if _, err := withoutKey.FetchFlightData(context.Background(), "GA404"); err == nil {
+		t.Error("expected an error without an API key")
+	}
+}
+
+func TestOpenSkyProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/states/all" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"time":1,"states":[
+			["8a0123","GIA404  ","Indonesia",1,1,106.6,-6.1,1000,false],
+			["8a0456","LNI101  ","Indonesia",1,1,106.6,-6.1,0,true]]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenSkyProvider(OpenSkyConfig{
+		URL:        server.URL,
+		IATAToICAO: map[string]string{"GA": "GIA"},
+		Airlines:   map[string]string{"GIA": "Garuda Indonesia"},
+	})
+
+	flight, err := provider.FetchFlightData(context.Background(), "GA404")
+	if err != nil {
+		t.Fatalf("FetchFlightData: %v", err)
+	}
+	if flight.FlightStatus != "active" || flight.AirlineName != "Garuda Indonesia" || flight.FlightNumber != "GA404" {
+		t.Errorf("unexpected flight data: %+v", flight)
+	}
+
+	flight, err = provider.FetchFlightData(context.Background(), "LNI101")
+	if err != nil {
+		t.Fatalf("FetchFlightData: %v", err)
+	}
+	if flight.FlightStatus != "on-ground" || flight.AirlineName != unknown {
+		t.Errorf("unexpected flight data: %+v", flight)
+	}
+
+	if _, err := provider.FetchFlightData(context.Background(), "QZ999"); !errors.Is(err, ErrFlightNotFound) {
+		t.Errorf("expected ErrFlightNotFound, got %v", err)
+	}
+}
+
+func TestNewProviderFromConfig(t *testing.T) {
+	staticFile := writeFile(t, "flights.json", `{"ga404":{"flight_number":"GA404","airline_name":"Garuda Indonesia"}}`)
+	registryFile := "../registry/aircraft.csv"
+
+	tests := []struct {
+		config   Config
+		flightID string
+		name     string
+		airline  string
+	}{
+		{Config{Provider: "static", Static: StaticConfig{File: staticFile}}, "GA404", "static", "Garuda Indonesia"},
+		{Config{Provider: "registry", Registry: RegistryConfig{File: registryFile}}, "pkgfa", "registry", "Garuda Indonesia"},
+	}
+	for _, tt := range tests {
+		provider, err := NewProvider(tt.config)
+		if err != nil {
+			t.Fatalf("NewProvider(%s): %v", tt.name, err)
+		}
+		if provider.Name() != tt.name {
+			t.Errorf("expected provider %s, got %s", tt.name, provider.Name())
+		}
+		flight, err := provider.FetchFlightData(context.Background(), tt.flightID)
+		if err != nil {
+			t.Fatalf("%s: FetchFlightData: %v", tt.name, err)
+		}
+		if flight.AirlineName != tt.airline {
+			t.Errorf("%s: expected airline %s, got %s", tt.name, tt.airline, flight.AirlineName)
+		}
+		if _, err := provider.FetchFlightData(context.Background(), "XX000"); !errors.Is(err, ErrFlightNotFound) {
+			t.Errorf("%s: expected ErrFlightNotFound, got %v", tt.name, err)
+		}
+	}
+
+	if provider, err := NewProvider(Config{}); err != nil || provider.Name() != "aviationstack" {
+		t.Errorf("expected aviationstack by default, got %v, %v", provider, err)
+	}
+	if _, err := NewProvider(Config{Provider: "carrier-pigeon"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+	if _, err := NewProvider(Config{Provider: "static"}); err == nil {
+		t.Error("expected an error for the static provider without a file")
+	}
+}
+
+func TestNewProviderWrapsRemoteSourcesOnly(t *testing.T) {
+	staticFile := writeFile(t, "flights.json", `{"ga404":{"flight_number":"GA404","airline_name":"Garuda Indonesia"}}`)
+	config := Config{
+		Provider:  "consensus",
+		Static:    StaticConfig{File: staticFile},
+		Registry:  RegistryConfig{File: "../registry/aircraft.csv"},
+		Consensus: ConsensusConfig{Sources: []string{"static", "registry", "opensky"}},
+		RateLimit: RateLimitConfig{Requests: 1, Per: time.Minute},
+		Cache:     CacheConfig{TTL: time.Hour, File: filepath.Join(t.TempDir(), "cache.json")},
+	}
+
+	provider, err := NewProvider(config)
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	sources := provider.(*ConsensusProvider).sources
+	if _, ok := sources[0].(*StaticProvider); !ok {
+		t.Errorf("expected the static source to be read as is, got %T", sources[0])
+	}
+	if _, ok := sources[1].(*RegistryProvider); !ok {
+		t.Errorf("expected the registry source to be read as is, got %T", sources[1])
+	}
+	if _, ok := sources[2].(*CachingProvider); !ok {
+		t.Errorf("expected the opensky source to be cached, got %T", sources[2])
+	}
+}
@@ -0,0 +1,198 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRateLimited is returned when a request would exceed the provider plan
+// before the caller's deadline, or the provider kept answering 429.
+var ErrRateLimited = errors.New("oracle rate limit reached")
+
+// StatusError is a non-200 answer from a provider API.
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is the delay asked for by a Retry-After header, if any
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API request failed with status code %d", e.StatusCode)
+}
+
+func newStatusError(resp *http.Response) *StatusError {
+	statusErr := &StatusError{StatusCode: resp.StatusCode}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		statusErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return statusErr
+}
+
+// RateLimitConfig matches outgoing requests to the provider plan: at most
+// Requests per Per, in bursts of up to Burst. A provider answering 429 is
+// retried up to MaxRetries times, waiting Backoff, doubled on each attempt,
+// or as long as its Retry-After header asks.
+type RateLimitConfig struct {
+	Requests   int           `yaml:"requests"`
+	Per        time.Duration `yaml:"per"`
+	Burst      int           `yaml:"burst"`
+	MaxRetries int           `yaml:"maxRetries"`
+	Backoff    time.Duration `yaml:"backoff"`
+}
+
+func (c RateLimitConfig) enabled() bool {
+	return c.Requests > 0 && c.Per > 0
+}
+
+// tokenBucket refills rate tokens per second up to burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(config RateLimitConfig, now func() time.Time) *tokenBucket {
+	burst := float64(config.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		now:    now,
+		rate:   float64(config.Requests) / config.Per.Seconds(),
+		burst:  burst,
+		tokens: burst,
+		last:   now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before
+// using it. Nothing is taken when the wait would exceed maxWait.
+func (b *tokenBucket) reserve(maxWait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if wait > maxWait {
+		return wait, false
+	}
+	b.tokens--
+	return wait, true
+}
+
+// RateLimitedProvider passes requests on to a provider at the configured
+// rate and backs off when the provider answers 429 Too Many Requests.
+type RateLimitedProvider struct {
+	next       FlightDataProvider
+	bucket     *tokenBucket
+	maxRetries int
+	backoff    time.Duration
+	sleep      func(ctx context.Context, d time.Duration) error
+
+	limited atomic.Uint64
+	retries atomic.Uint64
+}
+
+func NewRateLimitedProvider(next FlightDataProvider, config RateLimitConfig) *RateLimitedProvider {
+	provider := &RateLimitedProvider{
+		next:       next,
+		maxRetries: config.MaxRetries,
+		backoff:    config.Backoff,
+		sleep:      sleepContext,
+	}
+	if provider.backoff <= 0 {
+		provider.backoff = time.Second
+	}
+	if config.enabled() {
+		provider.bucket = newTokenBucket(config, time.Now)
+	}
+	return provider
+}
+
+func (p *RateLimitedProvider) Name() string {
+	return p.next.Name()
+}
+
+func (p *RateLimitedProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	for attempt := 0; ; attempt++ {
+		if err := p.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		flightData, err := p.next.FetchFlightData(ctx, flightID)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+			return flightData, err
+		}
+		if attempt >= p.maxRetries {
+			p.limited.Add(1)
+			return nil, fmt.Errorf("%s answered 429 after %d attempts: %w", p.next.Name(), attempt+1, ErrRateLimited)
+		}
+
+		delay := p.backoff << attempt
+		if statusErr.RetryAfter > delay {
+			delay = statusErr.RetryAfter
+		}
+		p.retries.Add(1)
+		if err := p.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait blocks until the plan allows another request. It fails right away
+// when that is later than the context deadline rather than holding the
+// caller until it times out.
+func (p *RateLimitedProvider) wait(ctx context.Context) error {
+	if p.bucket == nil {
+		return nil
+	}
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = time.Until(deadline)
+	}
+	delay, ok := p.bucket.reserve(maxWait)
+	if !ok {
+		p.limited.Add(1)
+		return fmt.Errorf("next %s request allowed in %s: %w", p.next.Name(), delay.Round(time.Second), ErrRateLimited)
+	}
+	return p.sleep(ctx, delay)
+}
+
+// Stats adds the rate limiter counters to those of the wrapped provider.
+func (p *RateLimitedProvider) Stats() Stats {
+	stats := statsOf(p.next)
+	stats.RateLimited += p.limited.Load()
+	stats.Retries += p.retries.Load()
+	return stats
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,77 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RefreshRegistry replaces the registry dataset at dest with a new dump
+// from source, an http(s) URL or a local file. The dump is parsed before
+// it replaces dest, so a broken download leaves the current registry in
+// place. It returns the number of aircraft in the new dataset.
+func RefreshRegistry(ctx context.Context, source, dest string, client *http.Client) (int, error) {
+	if source == "" || dest == "" {
+		return 0, fmt.Errorf("refreshing the registry needs a source and a destination file")
+	}
+
+	body, err := openRegistrySource(ctx, source, defaultHTTPClient(client))
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	// Keep the extension so the temporary file is parsed like dest
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".registry-*"+filepath.Ext(dest))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary registry file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to download aircraft registry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write aircraft registry: %v", err)
+	}
+
+	records, err := readRegistryFile(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, fmt.Errorf("aircraft registry from %s is empty", source)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return 0, fmt.Errorf("failed to replace aircraft registry: %v", err)
+	}
+	return len(records), nil
+}
+
+func openRegistrySource(ctx context.Context, source string, client *http.Client) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open registry source: %v", err)
+		}
+		return file, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download aircraft registry: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, newStatusError(resp)
+	}
+	return resp.Body, nil
+}
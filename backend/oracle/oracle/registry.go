@@ -0,0 +1,265 @@
+package oracle
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAircraftNotFound is returned when an aircraft is not in the registry.
+var ErrAircraftNotFound = errors.New("aircraft not found in registry")
+
+// AircraftRecord is an aircraft registry entry.
+type AircraftRecord struct {
+	Registration string `json:"registration"`
+	ICAOHex      string `json:"icaoHex"`
+	Operator     string `json:"operator"`
+	Manufacturer string `json:"manufacturer"`
+	AircraftType string `json:"aircraftType"`
+	SerialNumber string `json:"serialNumber"`
+	Status       string `json:"status"`
+}
+
+// AircraftLookup resolves a tail number or ICAO 24-bit address to its
+// registry entry.
+type AircraftLookup interface {
+	LookupAircraft(ctx context.Context, id string) (*AircraftRecord, error)
+}
+
+// RegistryConfig points at the registry dataset, a CSV dump with a header
+// row or a JSON list of AircraftRecord objects. Source is where the refresh
+// command downloads it from, a URL or a local file.
+type RegistryConfig struct {
+	File   string `yaml:"file"`
+	Source string `yaml:"source"`
+}
+
+// RegistryProvider answers with the operator and type of the aircraft
+// given in the report instead of a live flight.
+type RegistryProvider struct {
+	lookup AircraftLookup
+}
+
+func NewRegistryProvider(config RegistryConfig) (*RegistryProvider, error) {
+	registry, err := OpenRegistry(config.File)
+	if err != nil {
+		return nil, err
+	}
+	return &RegistryProvider{lookup: registry}, nil
+}
+
+// NewRegistryLookupProvider wraps any AircraftLookup as a provider.
+func NewRegistryLookupProvider(lookup AircraftLookup) *RegistryProvider {
+	return &RegistryProvider{lookup: lookup}
+}
+
+func (p *RegistryProvider) Name() string {
+	return "registry"
+}
+
+func (p *RegistryProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	record, err := p.lookup.LookupAircraft(ctx, flightID)
+	if errors.Is(err, ErrAircraftNotFound) {
+		return nil, fmt.Errorf("%w: %w", ErrFlightNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	flightData := unknownFlight(flightID)
+	flightData.AirlineName = record.Operator
+	flightData.AircraftType = record.AircraftType
+	return flightData, nil
+}
+
+// Registry is an aircraft registry loaded from a local dataset. It reloads
+// the file when it changes, so a refresh needs no restart.
+type Registry struct {
+	path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	byTail   map[string]AircraftRecord
+	byICAO24 map[string]AircraftRecord
+}
+
+// OpenRegistry loads the dataset at path.
+func OpenRegistry(path string) (*Registry, error) {
+	if path == "" {
+		return nil, fmt.Errorf("the aircraft registry needs a file")
+	}
+	registry := &Registry{path: path}
+	if err := registry.reload(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Len returns the number of aircraft in the registry.
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.byTail)
+}
+
+// reload reads the file again if it was modified since the last load.
+func (r *Registry) reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read aircraft registry: %v", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if info.ModTime().Equal(r.modTime) && r.byTail != nil {
+		return nil
+	}
+
+	records, err := readRegistryFile(r.path)
+	if err != nil {
+		return err
+	}
+	r.byTail = make(map[string]AircraftRecord, len(records))
+	r.byICAO24 = make(map[string]AircraftRecord, len(records))
+	for _, record := range records {
+		r.byTail[normalizeRegistration(record.Registration)] = record
+		if record.ICAOHex != "" {
+			r.byICAO24[normalizeRegistration(record.ICAOHex)] = record
+		}
+	}
+	r.modTime = info.ModTime()
+	return nil
+}
+
+func (r *Registry) LookupAircraft(ctx context.Context, id string) (*AircraftRecord, error) {
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	key := normalizeRegistration(id)
+	r.mu.Lock()
+	record, ok := r.byTail[key]
+	if !ok {
+		record, ok = r.byICAO24[key]
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("aircraft %s: %w", id, ErrAircraftNotFound)
+	}
+	return &record, nil
+}
+
+// normalizeRegistration makes PK-GFA, pk-gfa and PKGFA the same key.
+func normalizeRegistration(registration string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(registration), "-", ""))
+}
+
+func readRegistryFile(path string) ([]AircraftRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aircraft registry: %v", err)
+	}
+	defer file.Close()
+
+	var records []AircraftRecord
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.NewDecoder(file).Decode(&records)
+	} else {
+		records, err = parseRegistryCSV(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse aircraft registry %s: %v", path, err)
+	}
+	return records, nil
+}
+
+// registryColumns maps the header names used by FAA and EASA-style dumps
+// to AircraftRecord fields.
+var registryColumns = map[string]string{
+	"registration":    "registration",
+	"tail_number":     "registration",
+	"n-number":        "n-number",
+	"icao_hex":        "icaoHex",
+	"icao24":          "icaoHex",
+	"mode s code hex": "icaoHex",
+	"operator":        "operator",
+	"owner":           "operator",
+	"name":            "operator",
+	"manufacturer":    "manufacturer",
+	"aircraft_type":   "aircraftType",
+	"type":            "aircraftType",
+	"model":           "aircraftType",
+	"serial_number":   "serialNumber",
+	"serial number":   "serialNumber",
+	"msn":             "serialNumber",
+	"status":          "status",
+	"status code":     "status",
+}
+
+// parseRegistryCSV reads a CSV dump with a header row. Unknown columns are
+// ignored. FAA N-NUMBER columns lack the N prefix, which is added.
+func parseRegistryCSV(r io.Reader) ([]AircraftRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := registryColumns[name]; ok {
+			columns[field] = i
+		}
+	}
+	_, hasTail := columns["registration"]
+	_, hasNNumber := columns["n-number"]
+	if !hasTail && !hasNNumber {
+		return nil, fmt.Errorf("no registration column in header")
+	}
+
+	var records []AircraftRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		registration := field("registration")
+		if registration == "" && field("n-number") != "" {
+			registration = "N" + field("n-number")
+		}
+		if registration == "" {
+			continue
+		}
+		records = append(records, AircraftRecord{
+			Registration: registration,
+			ICAOHex:      field("icaoHex"),
+			Operator:     field("operator"),
+			Manufacturer: field("manufacturer"),
+			AircraftType: field("aircraftType"),
+			SerialNumber: field("serialNumber"),
+			Status:       field("status"),
+		})
+	}
+	return records, nil
+}
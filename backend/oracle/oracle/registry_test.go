@@ -0,0 +1,101 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistryLookup(t *testing.T) {
+	registry, err := OpenRegistry("../registry/aircraft.csv")
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+
+	for _, id := range []string{"PK-GFA", "pkgfa", "8A01A4"} {
+		record, err := registry.LookupAircraft(context.Background(), id)
+		if err != nil {
+			t.Fatalf("LookupAircraft(%s): %v", id, err)
+		}
+		if record.Operator != "Garuda Indonesia" || record.AircraftType != "737-86N" || record.SerialNumber != "35208" || record.Status != "Valid" {
+			t.Errorf("%s: unexpected record %+v", id, record)
+		}
+	}
+	if _, err := registry.LookupAircraft(context.Background(), "GA404"); !errors.Is(err, ErrAircraftNotFound) {
+		t.Errorf("expected ErrAircraftNotFound for a flight number, got %v", err)
+	}
+
+	provider := NewRegistryLookupProvider(registry)
+	if _, err := provider.FetchFlightData(context.Background(), "PK-XXX"); !errors.Is(err, ErrFlightNotFound) || !errors.Is(err, ErrAircraftNotFound) {
+		t.Errorf("expected the provider to report both not-found errors, got %v", err)
+	}
+}
+
+func TestParseRegistryCSVFAAColumns(t *testing.T) {
+	const faa = "\ufeffN-NUMBER,SERIAL NUMBER,MFR MDL CODE,NAME,STATUS CODE,MODE S CODE HEX,\n" +
+		"720AN  ,31546   ,1384420,AMERICAN AIRLINES INC      ,V,A9A5B1    ,\n"
+	records, err := parseRegistryCSV(strings.NewReader(faa))
+	if err != nil {
+		t.Fatalf("parseRegistryCSV: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	record := records[0]
+	if record.Registration != "N720AN" || record.ICAOHex != "A9A5B1" || record.Operator != "AMERICAN AIRLINES INC" || record.SerialNumber != "31546" {
+		t.Errorf("unexpected record %+v", record)
+	}
+
+	if _, err := parseRegistryCSV(strings.NewReader("operator,model\nGaruda,B738\n")); err == nil {
+		t.Error("expected an error without a registration column")
+	}
+}
+
+func TestRefreshRegistry(t *testing.T) {
+	dump, err := os.ReadFile("../registry/aircraft.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken.csv" {
+			w.Write([]byte("operator\nGaruda\n"))
+			return
+		}
+		w.Write(dump)
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "aircraft.csv")
+	if err := os.WriteFile(dest, []byte("registration,operator\nPK-GFA,Old Operator\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := OpenRegistry(dest)
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+
+	if _, err := RefreshRegistry(context.Background(), server.URL+"/broken.csv", dest, nil); err == nil {
+		t.Fatal("expected a broken dump to be rejected")
+	}
+	if record, err := registry.LookupAircraft(context.Background(), "PK-GFA"); err != nil || record.Operator != "Old Operator" {
+		t.Fatalf("expected the registry to be kept after a failed refresh, got %+v, %v", record, err)
+	}
+
+	count, err := RefreshRegistry(context.Background(), server.URL+"/aircraft.csv", dest, nil)
+	if err != nil || count != 7 {
+		t.Fatalf("RefreshRegistry: %d, %v", count, err)
+	}
+	// The file system may not see a new modification time within the
+	// same clock tick
+	os.Chtimes(dest, time.Now().Add(time.Second), time.Now().Add(time.Second))
+	record, err := registry.LookupAircraft(context.Background(), "PK-GFA")
+	if err != nil || record.Operator != "Garuda Indonesia" || registry.Len() != 7 {
+		t.Errorf("expected the refreshed registry to be reloaded, got %+v, %v", record, err)
+	}
+}
@@ -0,0 +1,54 @@
+package oracle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StaticConfig points the static provider at a JSON file mapping flight
+// identifiers to FlightData objects.
+type StaticConfig struct {
+	File string `yaml:"file"`
+}
+
+// StaticProvider serves flights from a JSON file loaded at start-up, for
+// demos and networks without internet access.
+type StaticProvider struct {
+	flights map[string]FlightData
+}
+
+func NewStaticProvider(config StaticConfig) (*StaticProvider, error) {
+	if config.File == "" {
+		return nil, fmt.Errorf("the static oracle provider needs a file")
+	}
+	data, err := os.ReadFile(config.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read static flight data: %v", err)
+	}
+
+	var flights map[string]FlightData
+	if err := json.Unmarshal(data, &flights); err != nil {
+		return nil, fmt.Errorf("failed to parse static flight data %s: %v", config.File, err)
+	}
+
+	provider := &StaticProvider{flights: make(map[string]FlightData, len(flights))}
+	for flightID, flight := range flights {
+		provider.flights[strings.ToUpper(flightID)] = flight
+	}
+	return provider, nil
+}
+
+func (p *StaticProvider) Name() string {
+	return "static"
+}
+
+func (p *StaticProvider) FetchFlightData(ctx context.Context, flightID string) (*FlightData, error) {
+	flight, ok := p.flights[strings.ToUpper(strings.TrimSpace(flightID))]
+	if !ok {
+		return nil, fmt.Errorf("no flight data found for flight ID %s: %w", flightID, ErrFlightNotFound)
+	}
+	return &flight, nil
+}
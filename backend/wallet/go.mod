@@ -1,3 +1,5 @@
 module aviation-compliance-dapp-wallet
 
-go 1.23.3
+go 1.23.2
+
+require github.com/mattn/go-sqlite3 v1.14.24
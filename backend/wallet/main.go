@@ -16,7 +16,10 @@ func main() {
 		log.Fatalf("Error loading identity: %v", err)
 	}
 
-	store := &wallet.FileWalletStore{}
+	store, err := wallet.NewWalletStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Error opening wallet store: %v", err)
+	}
 
 	wallet, err := wallet.NewWallet(identity, store)
 	if err != nil {
@@ -0,0 +1,89 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Identity is a Fabric client identity able to sign transactions. It
+// satisfies the fabric-gateway identity.Identity interface, so it can be
+// passed straight to client.Connect together with its Signer.
+type Identity interface {
+	MspID() string
+	Credentials() []byte
+	Signer() (Sign, error)
+}
+
+type X509Identity struct {
+	MSP  string `json:"msp"`
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+
+	signerProvider SignerProvider
+}
+
+func NewX509Identity(msp, cert, key string) *X509Identity {
+	return &X509Identity{
+		MSP:  msp,
+		Cert: cert,
+		Key:  key,
+	}
+}
+
+// X509IdentityFromJSON decodes an identity as written by ToJSON.
+func X509IdentityFromJSON(data []byte) (*X509Identity, error) {
+	var identity X509Identity
+	err := json.Unmarshal(data, &identity)
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (i *X509Identity) ToJSON() ([]byte, error) {
+	return json.Marshal(i)
+}
+
+func (i *X509Identity) MspID() string {
+	return i.MSP
+}
+
+// Credentials returns the PEM encoded certificate. The private key is never
+// part of the credentials sent to peers.
+func (i *X509Identity) Credentials() []byte {
+	return []byte(i.Cert)
+}
+
+// WithSignerProvider makes the identity sign through provider instead of
+// DefaultSignerProvider, e.g. to keep the key in an HSM.
+func (i *X509Identity) WithSignerProvider(provider SignerProvider) *X509Identity {
+	i.signerProvider = provider
+	return i
+}
+
+func (i *X509Identity) Signer() (Sign, error) {
+	provider := i.signerProvider
+	if provider == nil {
+		provider = DefaultSignerProvider
+	}
+	return provider(i.Key)
+}
+
+func LoadIdentityFromFiles(msp, certPath, keyPath string) (*X509Identity, error) {
+	cert, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %v", err)
+	}
+
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %v", err)
+	}
+
+	return &X509Identity{
+		MSP:  msp,
+		Cert: string(cert),
+		Key:  string(key),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package wallet
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+// Sign signs a message digest. It has the same signature as the
+// fabric-gateway identity.Sign type.
+type Sign func(digest []byte) ([]byte, error)
+
+// SignerProvider creates the Sign function for a PEM encoded private key.
+type SignerProvider func(keyPEM string) (Sign, error)
+
+// DefaultSignerProvider is used by identities that have no provider of
+// their own.
+var DefaultSignerProvider SignerProvider = NewPrivateKeySigner
+
+// NewPrivateKeySigner signs in process with an ECDSA or Ed25519 private key.
+func NewPrivateKeySigner(keyPEM string) (Sign, error) {
+	privateKey, err := ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		return func(digest []byte) ([]byte, error) {
+			return signECDSA(key, digest)
+		}, nil
+	case ed25519.PrivateKey:
+		return func(digest []byte) ([]byte, error) {
+			return ed25519.Sign(key, digest), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type")
+	}
+}
+
+// ParsePrivateKey decodes a PKCS#8 private key, falling back to SEC 1 EC
+// keys as written by some older tooling.
+func ParsePrivateKey(pemKey string) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing private key")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		ecKey, ecErr := x509.ParseECPrivateKey(block.Bytes)
+		if ecErr != nil {
+			return nil, fmt.Errorf("failed to parse private key: %v", err)
+		}
+		return ecKey, nil
+	}
+
+	return key, nil
+}
+
+type ecdsaSignature struct {
+	R *big.Int
+	S *big.Int
+}
+
+func signECDSA(key *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure S is less than half the order of the curve
+	curveOrder := key.Curve.Params().N
+	halfOrder := new(big.Int).Rsh(curveOrder, 1) // Divide curve order by 2
+
+	if s.Cmp(halfOrder) > 0 {
+		// If S is larger than half the order, take the complement of S
+		s.Sub(curveOrder, s)
+	}
+
+	// Create the ASN.1 DER encoded signature
+	sig := ecdsaSignature{R: r, S: s}
+	signature, err := asn1.Marshal(sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signature: %v", err)
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,128 @@
+package wallet
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteWalletStore keeps identities in a single SQLite database file, for
+// deployments where the API runs on one node. It is safe for concurrent use.
+type SQLiteWalletStore struct {
+	notifier
+
+	db *sql.DB
+}
+
+func NewSQLiteWalletStore(path string) (*SQLiteWalletStore, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wallet database: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS identities (
+		label      TEXT PRIMARY KEY,
+		content    BLOB NOT NULL,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create identities table: %v", err)
+	}
+
+	return &SQLiteWalletStore{db: db}, nil
+}
+
+func (s *SQLiteWalletStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteWalletStore) Put(label string, content []byte) error {
+	return s.put(label, content, nil, false)
+}
+
+func (s *SQLiteWalletStore) CompareAndPut(label string, expected, content []byte) error {
+	return s.put(label, content, expected, true)
+}
+
+func (s *SQLiteWalletStore) put(label string, content, expected []byte, compare bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to store identity: %v", err)
+	}
+	defer tx.Rollback()
+
+	var previous []byte
+	err = tx.QueryRow(`SELECT content FROM identities WHERE label = ?`, label).Scan(&previous)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to store identity: %v", err)
+	}
+	existed := err == nil
+
+	if compare && (existed != (expected != nil) || !bytes.Equal(previous, expected)) {
+		return ErrConflict
+	}
+
+	_, err = tx.Exec(`INSERT INTO identities (label, content) VALUES (?, ?)
+		ON CONFLICT(label) DO UPDATE SET content = excluded.content, updated_at = CURRENT_TIMESTAMP`,
+		label, content)
+	if err != nil {
+		return fmt.Errorf("failed to store identity: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to store identity: %v", err)
+	}
+
+	s.publish(putEvent(existed, previous, content), label)
+	return nil
+}
+
+func (s *SQLiteWalletStore) Get(label string) ([]byte, error) {
+	var content []byte
+	err := s.db.QueryRow(`SELECT content FROM identities WHERE label = ?`, label).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity: %v", err)
+	}
+	return content, nil
+}
+
+func (s *SQLiteWalletStore) Remove(label string) error {
+	result, err := s.db.Exec(`DELETE FROM identities WHERE label = ?`, label)
+	if err != nil {
+		return fmt.Errorf("failed to remove identity: %v", err)
+	}
+	if removed, err := result.RowsAffected(); err == nil && removed > 0 {
+		s.publish(IdentityRemoved, label)
+	}
+	return nil
+}
+
+func (s *SQLiteWalletStore) Exists(label string) bool {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM identities WHERE label = ?)`, label).Scan(&exists)
+	return err == nil && exists
+}
+
+func (s *SQLiteWalletStore) List() ([]string, error) {
+	rows, err := s.db.Query(`SELECT label FROM identities ORDER BY label`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %v", err)
+	}
+	defer rows.Close()
+
+	var labels []string
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, fmt.Errorf("failed to list identities: %v", err)
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
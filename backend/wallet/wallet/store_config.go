@@ -0,0 +1,43 @@
+package wallet
+
+import (
+	"fmt"
+	"os"
+)
+
+// NewWalletStoreFromEnv selects the WalletStore backend from WALLET_STORE:
+//
+//	memory (default)  in-process map, lost on restart
+//	sqlite            WALLET_SQLITE_PATH (default "wallet.db")
+//	vault             VAULT_ADDR, VAULT_TOKEN, VAULT_NAMESPACE,
+//	                  WALLET_VAULT_MOUNT (default "secret"),
+//	                  WALLET_VAULT_PATH (default "fabric-wallet")
+func NewWalletStoreFromEnv() (WalletStore, error) {
+	switch backend := os.Getenv("WALLET_STORE"); backend {
+	case "", "memory":
+		return &FileWalletStore{}, nil
+	case "sqlite":
+		return NewSQLiteWalletStore(envOrDefault("WALLET_SQLITE_PATH", "wallet.db"))
+	case "vault":
+		address := os.Getenv("VAULT_ADDR")
+		if address == "" {
+			return nil, fmt.Errorf("VAULT_ADDR is required for the vault wallet store")
+		}
+		return NewVaultWalletStore(VaultConfig{
+			Address:   address,
+			Token:     os.Getenv("VAULT_TOKEN"),
+			Namespace: os.Getenv("VAULT_NAMESPACE"),
+			Mount:     os.Getenv("WALLET_VAULT_MOUNT"),
+			Path:      envOrDefault("WALLET_VAULT_PATH", "fabric-wallet"),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown wallet store %q", backend)
+	}
+}
+
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,315 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testWalletStoreConformance runs the cases every WalletStore must pass.
+// newStore must return an empty store for each call.
+func testWalletStoreConformance(t *testing.T, newStore func(t *testing.T) WalletStore) {
+	t.Run("GetMissing", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.Get("missing"); !errors.Is(err, ErrIdentityNotFound) {
+			t.Fatalf("expected ErrIdentityNotFound, got %v", err)
+		}
+		if store.Exists("missing") {
+			t.Fatalf("expected missing identity not to exist")
+		}
+	})
+
+	t.Run("PutGet", func(t *testing.T) {
+		store := newStore(t)
+		content := []byte(`{"msp":"Org1MSP","cert":"cert","key":"key"}`)
+		if err := store.Put("user1", content); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		got, err := store.Get("user1")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Fatalf("expected %q, got %q", content, got)
+		}
+		if !store.Exists("user1") {
+			t.Fatalf("expected identity to exist")
+		}
+	})
+
+	t.Run("PutOverwrites", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Put("user1", []byte("first")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if err := store.Put("user1", []byte("second")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		got, err := store.Get("user1")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if string(got) != "second" {
+			t.Fatalf("expected overwritten content, got %q", got)
+		}
+	})
+
+	t.Run("BinaryContent", func(t *testing.T) {
+		store := newStore(t)
+		content := []byte{0x00, 0xff, 0x10, '\n', '"'}
+		if err := store.Put("binary", content); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		got, err := store.Get("binary")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Fatalf("expected %v, got %v", content, got)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Put("user1", []byte("content")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if err := store.Remove("user1"); err != nil {
+			t.Fatalf("Remove failed: %v", err)
+		}
+		if store.Exists("user1") {
+			t.Fatalf("expected identity to be removed")
+		}
+		if _, err := store.Get("user1"); !errors.Is(err, ErrIdentityNotFound) {
+			t.Fatalf("expected ErrIdentityNotFound after Remove, got %v", err)
+		}
+	})
+
+	t.Run("RemoveMissing", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Remove("missing"); err != nil {
+			t.Fatalf("expected removing a missing identity to succeed, got %v", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		store := newStore(t)
+		labels, err := store.List()
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(labels) != 0 {
+			t.Fatalf("expected empty store, got %v", labels)
+		}
+
+		for _, label := range []string{"user2", "admin", "user1"} {
+			if err := store.Put(label, []byte(label)); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+		}
+		if err := store.Remove("user2"); err != nil {
+			t.Fatalf("Remove failed: %v", err)
+		}
+
+		labels, err = store.List()
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		sort.Strings(labels)
+		if len(labels) != 2 || labels[0] != "admin" || labels[1] != "user1" {
+			t.Fatalf("expected [admin user1], got %v", labels)
+		}
+	})
+
+	t.Run("ConcurrentAccess", func(t *testing.T) {
+		store := newStore(t)
+		var wg sync.WaitGroup
+		for worker := 0; worker < 8; worker++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				own := fmt.Sprintf("user%d", worker)
+				for i := 0; i < 20; i++ {
+					if err := store.Put(own, []byte(strconv.Itoa(i))); err != nil {
+						t.Errorf("Put failed: %v", err)
+						return
+					}
+					if err := store.Put("shared", []byte(own)); err != nil {
+						t.Errorf("Put failed: %v", err)
+						return
+					}
+					if _, err := store.Get(own); err != nil {
+						t.Errorf("Get failed: %v", err)
+						return
+					}
+					store.Exists("shared")
+					if _, err := store.List(); err != nil {
+						t.Errorf("List failed: %v", err)
+						return
+					}
+				}
+				if err := store.Remove(own); err != nil {
+					t.Errorf("Remove failed: %v", err)
+				}
+			}(worker)
+		}
+		wg.Wait()
+
+		labels, err := store.List()
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(labels) != 1 || labels[0] != "shared" {
+			t.Fatalf("expected only the shared identity to remain, got %v", labels)
+		}
+	})
+
+	t.Run("CompareAndPut", func(t *testing.T) {
+		store := casStore(t, newStore(t))
+		if err := store.CompareAndPut("user1", nil, []byte("v1")); err != nil {
+			t.Fatalf("expected create to succeed, got %v", err)
+		}
+		if err := store.CompareAndPut("user1", nil, []byte("v1")); !errors.Is(err, ErrConflict) {
+			t.Fatalf("expected ErrConflict creating an existing identity, got %v", err)
+		}
+		if err := store.CompareAndPut("user1", []byte("stale"), []byte("v2")); !errors.Is(err, ErrConflict) {
+			t.Fatalf("expected ErrConflict for a stale expected value, got %v", err)
+		}
+		if err := store.CompareAndPut("missing", []byte("v1"), []byte("v2")); !errors.Is(err, ErrConflict) {
+			t.Fatalf("expected ErrConflict for a missing identity, got %v", err)
+		}
+		if err := store.CompareAndPut("user1", []byte("v1"), []byte("v2")); err != nil {
+			t.Fatalf("expected swap to succeed, got %v", err)
+		}
+		got, err := store.Get("user1")
+		if err != nil || string(got) != "v2" {
+			t.Fatalf("expected v2, got %q (%v)", got, err)
+		}
+	})
+
+	t.Run("ConcurrentCompareAndPut", func(t *testing.T) {
+		store := casStore(t, newStore(t))
+		if err := store.Put("counter", []byte("0")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+
+		const workers = 8
+		var wg sync.WaitGroup
+		for worker := 0; worker < workers; worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					current, err := store.Get("counter")
+					if err != nil {
+						t.Errorf("Get failed: %v", err)
+						return
+					}
+					value, _ := strconv.Atoi(string(current))
+					err = store.CompareAndPut("counter", current, []byte(strconv.Itoa(value+1)))
+					if err == nil {
+						return
+					}
+					if !errors.Is(err, ErrConflict) {
+						t.Errorf("CompareAndPut failed: %v", err)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		got, err := store.Get("counter")
+		if err != nil || string(got) != strconv.Itoa(workers) {
+			t.Fatalf("expected every increment to be applied once, got %q (%v)", got, err)
+		}
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		watchable, ok := newStore(t).(WatchableWalletStore)
+		if !ok {
+			t.Fatalf("store does not implement WatchableWalletStore")
+		}
+		events, cancel := watchable.Watch()
+
+		watchable.Put("user1", []byte("v1"))
+		watchable.Put("user1", []byte("v1"))
+		watchable.Put("user1", []byte("v2"))
+		watchable.Remove("user1")
+		watchable.Remove("user1")
+
+		expected := []WalletEvent{
+			{Type: IdentityAdded, Label: "user1"},
+			{Type: IdentityRotated, Label: "user1"},
+			{Type: IdentityRemoved, Label: "user1"},
+		}
+		for _, want := range expected {
+			select {
+			case got := <-events:
+				if got != want {
+					t.Fatalf("expected %+v, got %+v", want, got)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for %+v", want)
+			}
+		}
+
+		cancel()
+		cancel()
+		if _, open := <-events; open {
+			t.Fatalf("expected no further events and a closed channel after cancel")
+		}
+	})
+}
+
+func casStore(t *testing.T, store WalletStore) CASWalletStore {
+	t.Helper()
+	cas, ok := store.(CASWalletStore)
+	if !ok {
+		t.Fatalf("store does not implement CASWalletStore")
+	}
+	return cas
+}
+
+func TestFileWalletStoreConformance(t *testing.T) {
+	testWalletStoreConformance(t, func(t *testing.T) WalletStore {
+		return &FileWalletStore{}
+	})
+}
+
+func TestSQLiteWalletStoreConformance(t *testing.T) {
+	testWalletStoreConformance(t, func(t *testing.T) WalletStore {
+		store, err := NewSQLiteWalletStore(filepath.Join(t.TempDir(), "wallet.db"))
+		if err != nil {
+			t.Fatalf("failed to open SQLite store: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
+
+func TestVaultWalletStoreConformance(t *testing.T) {
+	testWalletStoreConformance(t, func(t *testing.T) WalletStore {
+		server := newFakeVault(t, "test-token")
+		return NewVaultWalletStore(VaultConfig{
+			Address: server.URL,
+			Token:   "test-token",
+			Path:    "fabric-wallet",
+		})
+	})
+}
+
+func TestVaultWalletStoreRejectsBadToken(t *testing.T) {
+	server := newFakeVault(t, "test-token")
+	store := NewVaultWalletStore(VaultConfig{Address: server.URL, Token: "wrong"})
+
+	if err := store.Put("user1", []byte("content")); err == nil {
+		t.Fatalf("expected Put with a bad token to fail")
+	}
+}
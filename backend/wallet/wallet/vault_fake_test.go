@@ -0,0 +1,123 @@
+package wallet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault implements the subset of the Vault KV v2 HTTP API used by
+// VaultWalletStore, with secrets kept in memory.
+type fakeVault struct {
+	token   string
+	mu      sync.Mutex
+	secrets map[string]map[string]interface{}
+	version map[string]int
+}
+
+func newFakeVault(t *testing.T, token string) *httptest.Server {
+	vault := &fakeVault{
+		token:   token,
+		secrets: make(map[string]map[string]interface{}),
+		version: make(map[string]int),
+	}
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (v *fakeVault) reply(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if body != nil {
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != v.token {
+		v.reply(w, http.StatusForbidden, map[string][]string{"errors": {"permission denied"}})
+		return
+	}
+
+	// /v1/<mount>/<data|metadata>/<path>
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/v1/"), "/", 3)
+	if len(parts) < 2 {
+		v.reply(w, http.StatusNotFound, map[string][]string{"errors": {}})
+		return
+	}
+	kind, path := parts[1], ""
+	if len(parts) == 3 {
+		path = parts[2]
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	switch {
+	case kind == "data" && r.Method == http.MethodPost:
+		var body struct {
+			Data    map[string]interface{} `json:"data"`
+			Options struct {
+				CAS *int `json:"cas"`
+			} `json:"options"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			v.reply(w, http.StatusBadRequest, map[string][]string{"errors": {err.Error()}})
+			return
+		}
+		if body.Options.CAS != nil && *body.Options.CAS != v.version[path] {
+			v.reply(w, http.StatusBadRequest, map[string][]string{
+				"errors": {"check-and-set parameter did not match the current version"},
+			})
+			return
+		}
+		v.secrets[path] = body.Data
+		v.version[path]++
+		v.reply(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"version": v.version[path]},
+		})
+
+	case kind == "data" && r.Method == http.MethodGet:
+		secret, ok := v.secrets[path]
+		if !ok {
+			v.reply(w, http.StatusNotFound, map[string][]string{"errors": {}})
+			return
+		}
+		v.reply(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     secret,
+				"metadata": map[string]interface{}{"version": v.version[path]},
+			},
+		})
+
+	case kind == "metadata" && r.Method == http.MethodDelete:
+		delete(v.secrets, path)
+		delete(v.version, path)
+		w.WriteHeader(http.StatusNoContent)
+
+	case kind == "metadata" && (r.Method == "LIST" || r.URL.Query().Get("list") == "true"):
+		prefix := strings.TrimSuffix(path, "/") + "/"
+		var keys []string
+		for key := range v.secrets {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, strings.TrimPrefix(key, prefix))
+			}
+		}
+		if len(keys) == 0 {
+			v.reply(w, http.StatusNotFound, map[string][]string{"errors": {}})
+			return
+		}
+		sort.Strings(keys)
+		v.reply(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"keys": keys},
+		})
+
+	default:
+		v.reply(w, http.StatusMethodNotAllowed, map[string][]string{"errors": {"unsupported operation"}})
+	}
+}
@@ -0,0 +1,263 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultConfig points a VaultWalletStore at a KV version 2 secrets engine.
+type VaultConfig struct {
+	Address    string
+	Token      string
+	Namespace  string
+	Mount      string // defaults to "secret"
+	Path       string // prefix under which identities are stored
+	HTTPClient *http.Client
+}
+
+// VaultWalletStore keeps identities in a HashiCorp Vault KV v2 secrets
+// engine so several API instances can share one wallet. It is safe for
+// concurrent use; CompareAndPut maps onto the engine's check-and-set.
+type VaultWalletStore struct {
+	notifier
+
+	config VaultConfig
+	client *http.Client
+}
+
+func NewVaultWalletStore(config VaultConfig) *VaultWalletStore {
+	config.Address = strings.TrimRight(config.Address, "/")
+	config.Path = strings.Trim(config.Path, "/")
+	if config.Mount == "" {
+		config.Mount = "secret"
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &VaultWalletStore{config: config, client: client}
+}
+
+type vaultSecret struct {
+	Identity string `json:"identity"`
+}
+
+func (s *VaultWalletStore) url(kind, label string) string {
+	path := s.config.Path
+	if label != "" {
+		if path != "" {
+			path += "/"
+		}
+		path += url.PathEscape(label)
+	}
+	return fmt.Sprintf("%s/v1/%s/%s/%s", s.config.Address, s.config.Mount, kind, path)
+}
+
+func (s *VaultWalletStore) do(method, url string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", s.config.Token)
+	if s.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.config.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach vault: %v", err)
+	}
+	return resp, nil
+}
+
+func vaultError(resp *http.Response) error {
+	var body struct {
+		Errors []string `json:"errors"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if len(body.Errors) > 0 {
+		return fmt.Errorf("vault request failed with status code %d: %s", resp.StatusCode, strings.Join(body.Errors, "; "))
+	}
+	return fmt.Errorf("vault request failed with status code %d", resp.StatusCode)
+}
+
+func (s *VaultWalletStore) Put(label string, content []byte) error {
+	previous, _, err := s.read(label)
+	if err != nil && !errors.Is(err, ErrIdentityNotFound) {
+		return err
+	}
+
+	if err := s.write(label, content, -1); err != nil {
+		return err
+	}
+	s.publish(putEvent(previous != nil, previous, content), label)
+	return nil
+}
+
+func (s *VaultWalletStore) CompareAndPut(label string, expected, content []byte) error {
+	previous, version, err := s.read(label)
+	if err != nil && !errors.Is(err, ErrIdentityNotFound) {
+		return err
+	}
+	existed := previous != nil
+	if existed != (expected != nil) || !bytes.Equal(previous, expected) {
+		return ErrConflict
+	}
+
+	if err := s.write(label, content, version); err != nil {
+		return err
+	}
+	s.publish(putEvent(existed, previous, content), label)
+	return nil
+}
+
+// write stores content, only if the current version still equals cas when
+// cas is not negative.
+func (s *VaultWalletStore) write(label string, content []byte, cas int) error {
+	body := map[string]interface{}{
+		"data": vaultSecret{Identity: base64.StdEncoding.EncodeToString(content)},
+	}
+	if cas >= 0 {
+		body["options"] = map[string]int{"cas": cas}
+	}
+
+	resp, err := s.do(http.MethodPost, s.url("data", label), body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		err := vaultError(resp)
+		if cas >= 0 && strings.Contains(err.Error(), "check-and-set") {
+			return ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *VaultWalletStore) Get(label string) ([]byte, error) {
+	content, _, err := s.read(label)
+	return content, err
+}
+
+// read returns the identity and the current secret version. A soft-deleted
+// secret has a version but no content.
+func (s *VaultWalletStore) read(label string) ([]byte, int, error) {
+	resp, err := s.do(http.MethodGet, s.url("data", label), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return nil, 0, vaultError(resp)
+	}
+
+	var body struct {
+		Data struct {
+			Data     *vaultSecret `json:"data"`
+			Metadata struct {
+				Version int `json:"version"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, 0, fmt.Errorf("failed to parse vault response: %v", err)
+	}
+	version := body.Data.Metadata.Version
+	if body.Data.Data == nil {
+		return nil, version, ErrIdentityNotFound
+	}
+
+	content, err := base64.StdEncoding.DecodeString(body.Data.Data.Identity)
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to decode identity: %v", err)
+	}
+	return content, version, nil
+}
+
+// Remove deletes every version of the identity along with its metadata.
+func (s *VaultWalletStore) Remove(label string) error {
+	existed := s.Exists(label)
+
+	resp, err := s.do(http.MethodDelete, s.url("metadata", label), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return vaultError(resp)
+	}
+	if existed {
+		s.publish(IdentityRemoved, label)
+	}
+	return nil
+}
+
+func (s *VaultWalletStore) Exists(label string) bool {
+	_, err := s.Get(label)
+	return err == nil
+}
+
+func (s *VaultWalletStore) List() ([]string, error) {
+	resp, err := s.do("LIST", s.url("metadata", ""), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, vaultError(resp)
+	}
+
+	var body struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse vault response: %v", err)
+	}
+
+	var labels []string
+	for _, key := range body.Data.Keys {
+		// Keys ending in "/" are nested folders, not identities
+		if strings.HasSuffix(key, "/") {
+			continue
+		}
+		label, err := url.PathUnescape(key)
+		if err != nil {
+			label = key
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
@@ -1,12 +1,16 @@
 package wallet
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"io/ioutil"
+	"sync"
 )
 
+// ErrIdentityNotFound is returned by a WalletStore when no identity is
+// stored under the requested label.
+var ErrIdentityNotFound = errors.New("identity not found")
+
 type Wallet struct {
 	store WalletStore
 }
@@ -19,40 +23,8 @@ type WalletStore interface {
 	List() ([]string, error)
 }
 
-type Identity interface {
-	toJSON() ([]byte, error)
-	fromJSON(data []byte) (Identity, error)
-}
-
-type X509Identity struct {
-	MSP  string `json:"msp"`
-	Cert string `json:"cert"`
-	Key  string `json:"key"`
-}
-
-func NewX509Identity(msp, cert, key string) *X509Identity {
-	return &X509Identity{
-		MSP:  msp,
-		Cert: cert,
-		Key:  key,
-	}
-}
-
-func (i *X509Identity) toJSON() ([]byte, error) {
-	return json.Marshal(i)
-}
-
-func (i *X509Identity) fromJSON(data []byte) (Identity, error) {
-	var identity X509Identity
-	err := json.Unmarshal(data, &identity)
-	if err != nil {
-		return nil, err
-	}
-	return &identity, nil
-}
-
-func (w *Wallet) Put(label string, identity Identity) error {
-	data, err := identity.toJSON()
+func (w *Wallet) Put(label string, identity *X509Identity) error {
+	data, err := identity.ToJSON()
 	if err != nil {
 		return err
 	}
@@ -86,25 +58,28 @@ func (w *Wallet) List() ([]string, error) {
 	return w.store.List()
 }
 
-func LoadIdentityFromFiles(msp, certPath, keyPath string) (*X509Identity, error) {
-	cert, err := ioutil.ReadFile(certPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read certificate: %v", err)
-	}
+// Store returns the backend the wallet reads and writes identities through.
+func (w *Wallet) Store() WalletStore {
+	return w.store
+}
 
-	key, err := ioutil.ReadFile(keyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read private key: %v", err)
+// Watch subscribes to changes in the wallet's store. It returns ok false
+// when the store does not publish changes.
+func (w *Wallet) Watch() (events <-chan WalletEvent, cancel func(), ok bool) {
+	watchable, ok := w.store.(WatchableWalletStore)
+	if !ok {
+		return nil, func() {}, false
 	}
+	events, cancel = watchable.Watch()
+	return events, cancel, true
+}
 
-	return &X509Identity{
-		MSP:  msp,
-		Cert: string(cert),
-		Key:  string(key),
-	}, nil
+// OpenWallet wraps an existing store without adding any identity to it.
+func OpenWallet(store WalletStore) *Wallet {
+	return &Wallet{store: store}
 }
 
-func NewWallet(identity Identity, store WalletStore) (*Wallet, error) {
+func NewWallet(identity *X509Identity, store WalletStore) (*Wallet, error) {
 
 	wallet := &Wallet{store: store}
 	err := wallet.Put("user_identity", identity)
@@ -115,37 +90,78 @@ func NewWallet(identity Identity, store WalletStore) (*Wallet, error) {
 	return wallet, nil
 }
 
+// FileWalletStore keeps identities in memory. It is safe for concurrent use.
 type FileWalletStore struct {
+	notifier
+
+	mu         sync.RWMutex
 	identities map[string][]byte
 }
 
 func (s *FileWalletStore) Put(label string, content []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identities == nil {
+		s.identities = make(map[string][]byte)
+	}
+	previous, existed := s.identities[label]
+	s.identities[label] = append([]byte(nil), content...)
+	s.publish(putEvent(existed, previous, content), label)
+	return nil
+}
+
+func (s *FileWalletStore) CompareAndPut(label string, expected, content []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.identities[label]
+	if existed != (expected != nil) || !bytes.Equal(previous, expected) {
+		return ErrConflict
+	}
+
 	if s.identities == nil {
 		s.identities = make(map[string][]byte)
 	}
-	s.identities[label] = content
+	s.identities[label] = append([]byte(nil), content...)
+	s.publish(putEvent(existed, previous, content), label)
 	return nil
 }
 
 func (s *FileWalletStore) Get(label string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	content, exists := s.identities[label]
 	if !exists {
-		return nil, errors.New("identity not found")
+		return nil, ErrIdentityNotFound
 	}
-	return content, nil
+	return append([]byte(nil), content...), nil
 }
 
 func (s *FileWalletStore) Remove(label string) error {
-	delete(s.identities, label)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.identities[label]; exists {
+		delete(s.identities, label)
+		s.publish(IdentityRemoved, label)
+	}
 	return nil
 }
 
 func (s *FileWalletStore) Exists(label string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	_, exists := s.identities[label]
 	return exists
 }
 
 func (s *FileWalletStore) List() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var labels []string
 	for label := range s.identities {
 		labels = append(labels, label)
@@ -0,0 +1,204 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newECDSAKeyPEM(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestX509IdentityJSONRoundTrip(t *testing.T) {
+	identity := NewX509Identity("Org1MSP", "cert-pem", "key-pem")
+
+	data, err := identity.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	decoded, err := X509IdentityFromJSON(data)
+	if err != nil {
+		t.Fatalf("X509IdentityFromJSON failed: %v", err)
+	}
+	if decoded.MSP != "Org1MSP" || decoded.Cert != "cert-pem" || decoded.Key != "key-pem" {
+		t.Fatalf("unexpected identity after round trip: %+v", decoded)
+	}
+}
+
+func TestX509IdentityCredentialsExcludeKey(t *testing.T) {
+	identity := NewX509Identity("Org1MSP", "cert-pem", "key-pem")
+
+	if identity.MspID() != "Org1MSP" {
+		t.Fatalf("expected MSP ID Org1MSP, got %s", identity.MspID())
+	}
+	if string(identity.Credentials()) != "cert-pem" {
+		t.Fatalf("expected credentials to be the certificate only, got %q", identity.Credentials())
+	}
+}
+
+func TestECDSASignerProducesLowSSignatures(t *testing.T) {
+	key, keyPEM := newECDSAKeyPEM(t)
+	var identity Identity = NewX509Identity("Org1MSP", "cert-pem", keyPEM)
+
+	sign, err := identity.Signer()
+	if err != nil {
+		t.Fatalf("Signer failed: %v", err)
+	}
+
+	halfOrder := new(big.Int).Rsh(key.Curve.Params().N, 1)
+	for i := 0; i < 20; i++ {
+		digest := sha256.Sum256([]byte{byte(i)})
+		signature, err := sign(digest[:])
+		if err != nil {
+			t.Fatalf("sign failed: %v", err)
+		}
+		if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature) {
+			t.Fatalf("signature does not verify")
+		}
+		var parsed ecdsaSignature
+		if _, err := asn1.Unmarshal(signature, &parsed); err != nil {
+			t.Fatalf("failed to parse signature: %v", err)
+		}
+		if parsed.S.Cmp(halfOrder) > 0 {
+			t.Fatalf("expected low-S signature")
+		}
+	}
+}
+
+func TestEd25519Signer(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	sign, err := NewPrivateKeySigner(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if err != nil {
+		t.Fatalf("NewPrivateKeySigner failed: %v", err)
+	}
+	signature, err := sign([]byte("message"))
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	if !ed25519.Verify(public, []byte("message"), signature) {
+		t.Fatalf("signature does not verify")
+	}
+}
+
+func TestSignerRejectsInvalidKey(t *testing.T) {
+	if _, err := NewX509Identity("Org1MSP", "cert", "not a key").Signer(); err == nil {
+		t.Fatalf("expected an error for an invalid private key")
+	}
+}
+
+func TestWithSignerProvider(t *testing.T) {
+	var received string
+	provider := func(keyPEM string) (Sign, error) {
+		received = keyPEM
+		return func(digest []byte) ([]byte, error) {
+			return []byte("hsm-signature"), nil
+		}, nil
+	}
+
+	identity := NewX509Identity("Org1MSP", "cert", "pkcs11:object=user1").WithSignerProvider(provider)
+	sign, err := identity.Signer()
+	if err != nil {
+		t.Fatalf("Signer failed: %v", err)
+	}
+	signature, _ := sign([]byte("digest"))
+	if string(signature) != "hsm-signature" || received != "pkcs11:object=user1" {
+		t.Fatalf("expected the custom signer provider to be used")
+	}
+}
+
+func TestLoadIdentityFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "priv_sk")
+	os.WriteFile(certPath, []byte("cert-pem"), 0o600)
+	os.WriteFile(keyPath, []byte("key-pem"), 0o600)
+
+	identity, err := LoadIdentityFromFiles("Org1MSP", certPath, keyPath)
+	if err != nil {
+		t.Fatalf("LoadIdentityFromFiles failed: %v", err)
+	}
+	if identity.Cert != "cert-pem" || identity.Key != "key-pem" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	if _, err := LoadIdentityFromFiles("Org1MSP", filepath.Join(dir, "missing"), keyPath); err == nil {
+		t.Fatalf("expected an error for a missing certificate")
+	}
+}
+
+func TestWalletPutGetRemove(t *testing.T) {
+	identity := NewX509Identity("Org1MSP", "cert-pem", "key-pem")
+	w, err := NewWallet(identity, &FileWalletStore{})
+	if err != nil {
+		t.Fatalf("NewWallet failed: %v", err)
+	}
+
+	if !w.Exists("user_identity") {
+		t.Fatalf("expected NewWallet to store the identity as user_identity")
+	}
+	if err := w.Put("admin", NewX509Identity("Org2MSP", "admin-cert", "admin-key")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	admin, err := w.Get("admin")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if admin.MSP != "Org2MSP" || admin.Cert != "admin-cert" {
+		t.Fatalf("unexpected identity: %+v", admin)
+	}
+
+	labels, err := w.List()
+	if err != nil || len(labels) != 2 {
+		t.Fatalf("expected 2 labels, got %v (%v)", labels, err)
+	}
+
+	if err := w.Remove("admin"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := w.Get("admin"); !errors.Is(err, ErrIdentityNotFound) {
+		t.Fatalf("expected ErrIdentityNotFound, got %v", err)
+	}
+}
+
+func TestOpenWalletUsesExistingStore(t *testing.T) {
+	store := &FileWalletStore{}
+	data, _ := NewX509Identity("Org1MSP", "cert", "key").ToJSON()
+	store.Put("existing", data)
+
+	w := OpenWallet(store)
+	if w.Store() != store {
+		t.Fatalf("expected Store to return the wrapped store")
+	}
+	if !w.Exists("existing") || w.Exists("user_identity") {
+		t.Fatalf("expected OpenWallet to leave the store contents unchanged")
+	}
+}
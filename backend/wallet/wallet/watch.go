@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrConflict is returned by CompareAndPut when the stored identity no
+// longer matches the expected content.
+var ErrConflict = errors.New("identity was modified concurrently")
+
+type WalletEventType string
+
+const (
+	IdentityAdded   WalletEventType = "added"
+	IdentityRotated WalletEventType = "rotated"
+	IdentityRemoved WalletEventType = "removed"
+)
+
+// WalletEvent reports a change to one identity in a store.
+type WalletEvent struct {
+	Type  WalletEventType
+	Label string
+}
+
+// CASWalletStore is implemented by stores that can replace an identity
+// only while it still holds the expected content. A nil expected value
+// means the label must not exist yet.
+type CASWalletStore interface {
+	WalletStore
+	CompareAndPut(label string, expected, content []byte) error
+}
+
+// WatchableWalletStore is implemented by stores that publish changes made
+// through them. Changes made by other processes sharing the same backend
+// are not observed.
+type WatchableWalletStore interface {
+	WalletStore
+	Watch() (<-chan WalletEvent, func())
+}
+
+const watchBufferSize = 16
+
+// notifier fans wallet events out to subscribers. Events are dropped for a
+// subscriber whose buffer is full rather than blocking the writer.
+type notifier struct {
+	mu          sync.Mutex
+	subscribers map[chan WalletEvent]struct{}
+}
+
+func (n *notifier) Watch() (<-chan WalletEvent, func()) {
+	ch := make(chan WalletEvent, watchBufferSize)
+
+	n.mu.Lock()
+	if n.subscribers == nil {
+		n.subscribers = make(map[chan WalletEvent]struct{})
+	}
+	n.subscribers[ch] = struct{}{}
+	n.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			n.mu.Lock()
+			delete(n.subscribers, ch)
+			n.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+func (n *notifier) publish(eventType WalletEventType, label string) {
+	if eventType == "" {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.subscribers {
+		select {
+		case ch <- WalletEvent{Type: eventType, Label: label}:
+		default:
+		}
+	}
+}
+
+// putEvent returns the event for replacing previous with content, or an
+// empty type when nothing changed.
+func putEvent(existed bool, previous, content []byte) WalletEventType {
+	if !existed {
+		return IdentityAdded
+	}
+	if string(previous) != string(content) {
+		return IdentityRotated
+	}
+	return ""
+}